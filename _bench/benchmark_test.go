@@ -1,7 +1,9 @@
 package _bench
 
 import (
+	"context"
 	"github.com/mbict/go-eventbus/v2"
+	"runtime"
 	"sync"
 	"testing"
 )
@@ -46,6 +48,16 @@ func BenchmarkAsyncBus(b *testing.B) {
 	publishEventsLoop(b, eb, e)
 }
 
+func BenchmarkAsyncPoolBus(b *testing.B) {
+	eb := eventbus.NewAsyncPool(runtime.NumCPU(), 1000)
+	eb.Subscribe(eventbus.EventHandlerFunc(eventHandler))
+	e := testEvent{}
+
+	publishEventsLoop(b, eb, e)
+
+	eb.Close(context.Background())
+}
+
 func BenchmarkChanneldWithSimpleBus(b *testing.B) {
 
 	eb, cancelFunc := eventbus.NewChanneldWith(eventbus.New())
@@ -1,82 +1,303 @@
 package eventbus
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
+// AsyncEventBus is an event bus that runs the handlers asynchronously from the publisher
+type AsyncEventBus interface {
+	EventBus
+
+	Closer
+
+	// PublishAsync publishes the event and returns a result to wait for the completion of all the handlers.
+	PublishAsync(ctx context.Context, event any) *PublishResult
+}
+
 type asyncEventBus struct {
-	handlers         map[EventName]eventHandlers
-	nameResolver     EventNameResolver
-	errorHandlerFunc PublishErrorHandlerFunc
-	mu               sync.RWMutex
+	handlerRegistry
+	nameResolver      nameResolver
+	errorHandlerFunc  PublishErrorHandlerFunc
+	dispatcher        dispatcher
+	publishMiddleware publishMiddlewares
+	source            string
+	deadLetterQueue   DeadLetterQueue
+	eventStore        EventStore
+	repanic           bool
+	pending           pendingTracker
+	closed            bool
+	mu                sync.RWMutex
+	// retryHold is set by a dispatcher that keeps the events in order, the backoff of a retry is waited out by the
+	// handling goroutine until the channel is closed
+	retryHold <-chan struct{}
 }
 
-func (eb *asyncEventBus) setEventResolver(resolver EventNameResolver) {
-	eb.nameResolver = resolveEventName
+func (eb *asyncEventBus) setEventResolver(resolver nameResolver) {
+	eb.nameResolver = resolver
+}
+
+func (eb *asyncEventBus) eventName(event any) (EventName, error) {
+	return eb.nameResolver.Resolve(event)
 }
 
 func (eb *asyncEventBus) setErrorHandler(errorHandler PublishErrorHandlerFunc) {
 	eb.errorHandlerFunc = errorHandler
 }
 
-func (eb *asyncEventBus) Subscribe(handler EventHandler, events ...EventName) {
+func (eb *asyncEventBus) reportError(err error, event any) error {
+	publishErr := eb.errorHandlerFunc.handle(err, event)
+	if eb.repanic {
+		repanicWhenRecovered(err)
+	}
+	return publishErr
+}
+
+func (eb *asyncEventBus) setRepanic(repanic bool) {
+	eb.repanic = repanic
+}
+
+func (eb *asyncEventBus) setSource(source string) {
+	eb.source = source
+}
+
+func (eb *asyncEventBus) setDeadLetterQueue(queue DeadLetterQueue) {
+	eb.deadLetterQueue = queue
+}
+
+func (eb *asyncEventBus) setEventStore(store EventStore) {
+	eb.eventStore = store
+}
+
+func (eb *asyncEventBus) findSubscription(handlerName string) (*subscription, bool) {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+	return eb.handlerRegistry.findSubscription(handlerName)
+}
+
+func (eb *asyncEventBus) addPublishMiddleware(middleware ...PublishMiddleware) {
+	eb.publishMiddleware = append(eb.publishMiddleware, middleware...)
+}
+
+func (eb *asyncEventBus) Subscribe(handler EventHandler, events ...EventName) Subscription {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
-	if len(events) == 0 {
-		eb.handlers["*"] = append(eb.handlers["*"], handler)
-		return
-	}
+	return eb.subscribe(handler, events, eb.removeSubscription)
+}
 
-	for _, eventType := range events {
-		eb.handlers[eventType] = append(eb.handlers[eventType], handler)
-	}
+func (eb *asyncEventBus) removeSubscription(s *subscription) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	eb.remove(s)
 }
 
 func (eb *asyncEventBus) Unsubscribe(handler EventHandler, events ...EventName) {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
+	eb.unsubscribeHandler(handler, events)
+}
 
-	if len(events) == 0 {
-		for eventType := range eb.handlers {
-			eb.handlers[eventType] = removeHandlerFromSlice(eb.handlers[eventType], handler)
-			if len(eb.handlers[eventType]) == 0 {
-				delete(eb.handlers, eventType)
-			}
-		}
+func (eb *asyncEventBus) Publish(event any) error {
+	return eb.PublishContext(context.Background(), event)
+}
+
+func (eb *asyncEventBus) PublishContext(ctx context.Context, event any) error {
+	ctx, event = withEnvelope(ctx, event, eb.source)
+	if len(eb.publishMiddleware) == 0 {
+		return eb.publish(ctx, event, nil)
 	}
+	return eb.publishMiddleware.then(func(ctx context.Context, event any) error {
+		return eb.publish(ctx, event, nil)
+	})(ctx, event)
+}
 
-	for _, eventType := range events {
-		eb.handlers[eventType] = removeHandlerFromSlice(eb.handlers[eventType], handler)
-		if len(eb.handlers[eventType]) == 0 {
-			delete(eb.handlers, eventType)
-		}
+func (eb *asyncEventBus) PublishAsync(ctx context.Context, event any) *PublishResult {
+	ctx, event = withEnvelope(ctx, event, eb.source)
+	result := newPublishResult()
+	publish := func(ctx context.Context, event any) error {
+		return eb.publish(ctx, event, result)
+	}
+
+	//a middleware could stop the event before it reaches the handlers
+	if err := eb.publishMiddleware.then(publish)(ctx, event); !result.started() {
+		result.complete(err)
 	}
+	return result
 }
 
-func (eb *asyncEventBus) Publish(event any) error {
+// publish dispatches the handlers of the event, the outcome of every handler is registered in the optional result
+func (eb *asyncEventBus) publish(ctx context.Context, event any, result *PublishResult) error {
+	if err := ctx.Err(); err != nil {
+		result.complete(err)
+		return err
+	}
+
 	eb.mu.RLock()
-	defer eb.mu.RUnlock()
+	if eb.closed {
+		eb.mu.RUnlock()
+		result.complete(ErrClosed)
+		return ErrClosed
+	}
 
-	for _, handler := range eb.handlers[eb.nameResolver(event)] {
-		go func(handler EventHandler) {
-			handler.Handle(event)
-		}(handler)
+	name, err := eb.nameResolver.Resolve(event)
+	if err == nil {
+		//the event is only dispatched when it is stored
+		ctx, err = storeEvent(ctx, eb.eventStore, name, event)
+	}
+	if err != nil {
+		eb.mu.RUnlock()
+		result.complete(err)
+		return err
+	}
+
+	handlers := eb.lookup(name)
+	patternHandlers := eb.match(name)
+	catchAllHandlers := eb.lookup("*")
+	if len(handlers)+len(patternHandlers)+len(catchAllHandlers) == 0 {
+		eb.mu.RUnlock()
+		result.complete(nil)
+		return nil
+	}
+
+	//the event is pending until all the handlers are done
+	if result == nil {
+		result = newPublishResult()
+	}
+	eb.pending.add(1)
+	result.release = eb.pending.done
+	result.add(len(handlers) + len(patternHandlers) + len(catchAllHandlers))
+	eb.mu.RUnlock()
+
+	//the lock is released before dispatching, as the dispatcher could block when its queue is full
+	for _, sub := range handlers {
+		err = eb.dispatchJob(asyncJob{ctx: ctx, name: name, event: event, sub: sub, attempt: 1, result: result}, err)
+	}
+
+	//pattern event handlers
+	for _, sub := range patternHandlers {
+		err = eb.dispatchJob(asyncJob{ctx: ctx, name: name, event: event, sub: sub, attempt: 1, result: result}, err)
 	}
 
 	//catchall event handlers
-	for _, handler := range eb.handlers["*"] {
-		go func(handler EventHandler) {
-			handler.Handle(event)
-		}(handler)
+	for _, sub := range catchAllHandlers {
+		err = eb.dispatchJob(asyncJob{ctx: ctx, name: name, event: event, sub: sub, attempt: 1, result: result}, err)
 	}
-	return nil
+	return err
 }
 
-func NewAsync(options ...Option) EventBus {
-	eb := &asyncEventBus{
-		handlers:     make(eventChannels),
-		nameResolver: resolveEventName,
+// dispatchJob dispatches the job and returns the first dispatch error
+func (eb *asyncEventBus) dispatchJob(job asyncJob, err error) error {
+	if dispatchErr := eb.dispatcher.dispatch(job); dispatchErr != nil {
+		job.result.finish(dispatchErr)
+		if err == nil {
+			return dispatchErr
+		}
+	}
+	return err
+}
+
+// handle runs the handler, errors are passed to the error handler as there is no publisher waiting for them.
+// The error handler can be called concurrently.
+func (eb *asyncEventBus) handle(job asyncJob) {
+	//the context could be cancelled before the handler got scheduled
+	if err := job.ctx.Err(); err != nil {
+		job.result.finish(err)
+		return
+	}
+
+	err := recoverHandler(job.ctx, job.sub.dispatch, job.name, job.event)
+	for {
+		delay, retry := job.sub.retry.next(job.attempt, err)
+		if !retry {
+			break
+		}
+		if eb.retryHold == nil {
+			eb.retry(job, delay)
+			return
+		}
+
+		//the retry is handled in place, the next events of the partition wait for it
+		if waitErr := eb.holdRetry(job.ctx, delay); waitErr != nil {
+			job.result.finish(eb.errorHandlerFunc.handle(job.sub.retry.result(job.attempt, waitErr), job.event))
+			return
+		}
+		job.attempt++
+		err = recoverHandler(job.ctx, job.sub.dispatch, job.name, job.event)
+	}
+
+	err = job.sub.retry.result(job.attempt, err)
+	if err != nil {
+		err = deadLetter(job.ctx, eb.deadLetterQueue, job.sub, job.name, job.event, err)
+	}
+	if err == nil {
+		job.result.finish(nil)
+		return
+	}
+
+	job.result.finish(eb.errorHandlerFunc.handle(err, job.event))
+	if eb.repanic {
+		repanicWhenRecovered(err)
+	}
+}
+
+// retry dispatches the job again after the delay, the event stays pending until the last attempt is done
+func (eb *asyncEventBus) retry(job asyncJob, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		attempt := job.attempt
+		job.attempt++
+		if err := eb.dispatcher.dispatch(job); err != nil {
+			job.result.finish(eb.errorHandlerFunc.handle(job.sub.retry.result(attempt, err), job.event))
+		}
+	})
+}
+
+// holdRetry waits out the backoff, an error is returned when the context is done or the dispatcher is stopped
+func (eb *asyncEventBus) holdRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-eb.retryHold:
+		return ErrClosed
+	}
+}
+
+// drop reports a job that is dropped by the dispatcher to the error handler
+func (eb *asyncEventBus) drop(job asyncJob, err error) {
+	job.result.finish(eb.errorHandlerFunc.handle(err, job.event))
+}
+
+func (eb *asyncEventBus) Drain(ctx context.Context) error {
+	return eb.pending.wait(ctx)
+}
+
+// Close stops accepting new events and waits for the in-flight handlers. Handlers that are still running
+// when the context is done are not stopped, their events are reported as abandoned. The queued handler calls
+// are not run anymore, their publish results finish with ErrClosed.
+func (eb *asyncEventBus) Close(ctx context.Context) (int, error) {
+	eb.mu.Lock()
+	eb.closed = true
+	eb.mu.Unlock()
+
+	err := eb.Drain(ctx)
+	abandoned := eb.pending.pending()
+	eb.dispatcher.stop()
+
+	if err != nil {
+		return abandoned, err
 	}
+	return 0, nil
+}
+
+// NewAsync creates a bus that runs every handler in its own goroutine
+func NewAsync(options ...Option) AsyncEventBus {
+	eb := newAsyncEventBus()
+	eb.dispatcher = goroutineDispatcher(eb.handle)
 
 	for _, option := range options {
 		option(eb)
@@ -84,3 +305,48 @@ func NewAsync(options ...Option) EventBus {
 
 	return eb
 }
+
+func newAsyncEventBus() *asyncEventBus {
+	return &asyncEventBus{
+		handlerRegistry: newHandlerRegistry(),
+		nameResolver:    defaultNameResolver,
+	}
+}
+
+type asyncJob struct {
+	ctx     context.Context
+	name    EventName
+	event   any
+	sub     *subscription
+	attempt int
+	result  *PublishResult
+}
+
+// dispatcher schedules the handler jobs of the async bus
+type dispatcher interface {
+	// dispatch schedules the job, an error is returned when the job is rejected
+	dispatch(job asyncJob) error
+	// stop stops the dispatcher, jobs still queued are abandoned
+	stop()
+}
+
+// abandonJobs finishes the jobs left in the queue of a stopped dispatcher with ErrClosed
+func abandonJobs(jobs chan asyncJob) {
+	for {
+		select {
+		case job := <-jobs:
+			job.result.finish(ErrClosed)
+		default:
+			return
+		}
+	}
+}
+
+type goroutineDispatcher func(asyncJob)
+
+func (d goroutineDispatcher) dispatch(job asyncJob) error {
+	go d(job)
+	return nil
+}
+
+func (d goroutineDispatcher) stop() {}
@@ -0,0 +1,96 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncEventBusRoutesErrorsToErrorHandler(t *testing.T) {
+	expectedErr := errors.New("handler error")
+	errs := make(chan error, 1)
+
+	bus := NewAsync(WithErrorHandler(func(err error, event any) error {
+		errs <- err
+		return nil
+	}))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		return expectedErr
+	}), EventA)
+
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+
+	select {
+	case err := <-errs:
+		assert.Equal(t, expectedErr, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected the error handler to be called")
+	}
+}
+
+func TestAsyncEventBusPublishAsyncJoinsErrors(t *testing.T) {
+	err1 := errors.New("handler error 1")
+	err2 := errors.New("handler error 2")
+
+	bus := NewAsync()
+	bus.Subscribe(EventHandlerFunc(func(event any) error { return err1 }), EventA)
+	bus.Subscribe(EventHandlerFunc(func(event any) error { return nil }), EventA)
+	bus.Subscribe(EventHandlerFunc(func(event any) error { return err2 }))
+
+	err := bus.PublishAsync(context.Background(), &TestEventA{}).Wait()
+
+	assert.ErrorIs(t, err, err1)
+	assert.ErrorIs(t, err, err2)
+}
+
+func TestAsyncEventBusPublishAsyncWaitsForHandlers(t *testing.T) {
+	var mu sync.Mutex
+	handled := 0
+
+	bus := NewAsync()
+	for i := 0; i < 3; i++ {
+		bus.Subscribe(EventHandlerFunc(func(event any) error {
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			handled++
+			mu.Unlock()
+			return nil
+		}), EventA)
+	}
+
+	err := bus.PublishAsync(context.Background(), &TestEventA{}).Wait()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, handled)
+}
+
+func TestAsyncEventBusPublishAsyncErrorHandlerFiltersErrors(t *testing.T) {
+	bus := NewAsync(WithErrorHandler(func(err error, event any) error {
+		return nil
+	}))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		return errors.New("handled error")
+	}), EventA)
+
+	assert.NoError(t, bus.PublishAsync(context.Background(), &TestEventA{}).Wait())
+}
+
+func TestAsyncEventBusPublishAsyncWithoutHandlers(t *testing.T) {
+	bus := NewAsync()
+
+	assert.NoError(t, bus.PublishAsync(context.Background(), &TestEventA{}).Wait())
+}
+
+func TestAsyncEventBusPublishAsyncCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	bus := NewAsync()
+	bus.Subscribe(EventHandlerFunc(func(event any) error { return nil }))
+
+	assert.ErrorIs(t, bus.PublishAsync(ctx, &TestEventA{}).Wait(), context.Canceled)
+}
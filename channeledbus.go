@@ -1,45 +1,172 @@
 package eventbus
 
-import "sync"
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+)
 
 type CancelFunc func()
 
+type channeledEvent struct {
+	ctx   context.Context
+	event any
+	retry func()
+}
+
 type channeledEventBus struct {
 	EventBus
-	c chan any
+	c       chan channeledEvent
+	stop    func()
+	stopped chan struct{}
+	pending pendingTracker
+	closed  bool
+	mu      sync.RWMutex
 }
 
 func (eb *channeledEventBus) Publish(event any) (err error) {
-	eb.c <- event
+	return eb.PublishContext(context.Background(), event)
+}
+
+func (eb *channeledEventBus) PublishContext(ctx context.Context, event any) error {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	if eb.closed {
+		return ErrClosed
+	}
+
+	//the envelope is created when the event is published, not when it leaves the queue
+	eb.pending.add(1)
+	select {
+	case eb.c <- channeledEvent{ctx: ctx, event: newEnvelope(ctx, event, "")}:
+		return nil
+	case <-ctx.Done():
+		eb.pending.done()
+		return ctx.Err()
+	case <-eb.stopped:
+		eb.pending.done()
+		return ErrClosed
+	}
+}
+
+// scheduleRetry queues the retry of a failed handler call after the delay, so the loop is not blocked by the backoff
+func (eb *channeledEventBus) scheduleRetry(delay time.Duration, retry func()) {
+	eb.pending.add(1)
+	time.AfterFunc(delay, func() {
+		select {
+		case eb.c <- channeledEvent{retry: retry}:
+		case <-eb.stopped:
+			eb.pending.done()
+		}
+	})
+}
+
+func (eb *channeledEventBus) findSubscription(handlerName string) (*subscription, bool) {
+	return findSubscription(eb.EventBus, handlerName)
+}
+
+func (eb *channeledEventBus) eventName(event any) (EventName, error) {
+	return busEventName(eb.EventBus, event)
+}
+
+// Drain waits until the queued events are handled. When the wrapped bus has a lifecycle,
+// it is drained as well.
+func (eb *channeledEventBus) Drain(ctx context.Context) error {
+	if err := eb.pending.wait(ctx); err != nil {
+		return err
+	}
+	if closer, ok := eb.EventBus.(Closer); ok {
+		return closer.Drain(ctx)
+	}
 	return nil
 }
 
-func NewChanneldWith(eventBus EventBus) (EventBus, CancelFunc) {
-	done := make(chan bool)
+// Close stops accepting new events and handles the queued events before the background loop is stopped.
+// The queued and in-flight events when the context is done are abandoned. The wrapped bus is not closed.
+func (eb *channeledEventBus) Close(ctx context.Context) (int, error) {
+	eb.mu.Lock()
+	eb.closed = true
+	eb.mu.Unlock()
+
+	err := eb.Drain(ctx)
+	eb.stop()
+
+	if err != nil {
+		return eb.pending.pending(), err
+	}
+	return 0, nil
+}
+
+// publish publishes the queued event on the wrapped bus. A panic outside the handlers, like in the wrapped bus,
+// a publish middleware or a name resolver, is passed to the error handler of the wrapped bus as HandlerPanicError.
+func (eb *channeledEventBus) publish(e channeledEvent) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		//a handler panic repanicked by the wrapped bus is not recovered again
+		if p, ok := r.(*HandlerPanicError); ok {
+			panic(p)
+		}
+
+		event := e.event
+		if envelope, ok := event.(*Envelope); ok {
+			event = envelope.Event
+		}
+		_ = reportError(eb.EventBus, &HandlerPanicError{Event: event, Value: r, Stack: debug.Stack()}, event)
+	}()
+
+	if e.retry != nil {
+		e.retry()
+	} else if e.ctx.Err() == nil {
+		//events cancelled while waiting in the queue are skipped
+		_ = eb.EventBus.PublishContext(withRetryScheduler(e.ctx, eb), e.event)
+	}
+}
+
+func (eb *channeledEventBus) reportError(err error, event any) error {
+	return reportError(eb.EventBus, err, event)
+}
+
+// NewChanneldWith queues the published events and publishes them from a background loop on the provided bus.
+// The cancel func stops the loop immediately, abandoning the queued events, use Close for a graceful shutdown.
+func NewChanneldWith(eventBus EventBus) (ClosableEventBus, CancelFunc) {
+	done := make(chan struct{})
 	eb := &channeledEventBus{
 		EventBus: eventBus,
-		c:        make(chan any, 100),
+		c:        make(chan channeledEvent, 100),
+		stopped:  make(chan struct{}),
 	}
 
 	go func() {
-		for true {
+		defer close(eb.stopped)
+		for {
+			//a stop takes precedence over the queued events
+			select {
+			case <-done:
+				return
+			default:
+			}
+
 			select {
-			case event := <-eb.c:
-				_ = eb.EventBus.Publish(event)
+			case e := <-eb.c:
+				eb.publish(e)
+				eb.pending.done()
 			case <-done:
-				close(eb.c)
-				close(done)
 				return
 			}
 		}
 	}()
 
 	var once sync.Once
-	cancelFunc := func() {
+	eb.stop = func() {
 		once.Do(func() {
-			done <- true
+			close(done)
 		})
 	}
 
-	return eb, cancelFunc
+	return eb, CancelFunc(eb.stop)
 }
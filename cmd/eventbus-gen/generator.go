@@ -0,0 +1,888 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/mbict/go-eventbus/v2/internal/handlername"
+)
+
+const eventbusPath = "github.com/mbict/go-eventbus/v2"
+
+// handlerMethodsTag is the struct tag listing the handler methods of a type, see eventbus.HandlerMethods
+const handlerMethodsTag = "eventbus"
+
+// handlerMethod is a handler method found in the package
+type handlerMethod struct {
+	Method string
+	// Param is the type of the event argument as written in the source
+	Param string
+	// Value is the value type of a pointer argument, empty when the argument is not a pointer
+	Value    string
+	Wildcard bool
+	Context  bool
+	// Envelope is the type of the envelope argument, empty without an envelope argument
+	Envelope string
+	// Results is none, error or followUps
+	Results string
+	// imports holds the package of an imported argument type
+	imports map[string]string
+}
+
+// TypeString returns the argument type like reflect does, used in the type mismatch errors
+func (m handlerMethod) TypeString(pkg string) string {
+	if strings.Contains(m.Param, ".") {
+		return m.Param
+	}
+	if m.Value != "" {
+		return "*" + pkg + "." + m.Value
+	}
+	return pkg + "." + m.Param
+}
+
+// Call returns the statement calling the method with the event and returning its follow-up events
+func (m handlerMethod) Call(event string) string {
+	args := []string{event}
+	if m.Context {
+		args = append([]string{"ctx"}, args...)
+	}
+	switch m.Envelope {
+	case "*eventbus.Envelope":
+		args = append(args, "envelope")
+	case "eventbus.Envelope":
+		args = append(args, "*envelope")
+	}
+
+	call := "h." + m.Method + "(" + strings.Join(args, ", ") + ")"
+	switch m.Results {
+	case "none":
+		return call + "\nreturn nil, nil"
+	case "error":
+		return "return nil, " + call
+	}
+	return "return " + call
+}
+
+// handlerType is a type with handler methods
+type handlerType struct {
+	Name    string
+	Pointer bool
+	Methods []handlerMethod
+	// errs holds the methods with a handler name that are not a handler
+	errs []methodError
+}
+
+type methodError struct {
+	method string
+	err    string
+}
+
+// generator scans a package for the handler methods and the event implementations
+type generator struct {
+	fset    *token.FileSet
+	pkgName string
+	// events holds the local types declaring the EventName method, true when EventName has a pointer receiver
+	events map[string]bool
+	types  map[string]*handlerType
+	// specs holds the local type declarations
+	specs map[string]*ast.TypeSpec
+	// methods holds the names of the methods declared on the local types
+	methods map[string]map[string]bool
+	// selected holds the handler methods selected by a type, like the eventbus.HandlerMethods
+	selected map[string]map[string]bool
+	// selectErrs holds the types with a HandlerMethods method the generator cannot evaluate
+	selectErrs map[string]string
+}
+
+// generate parses the go files of the package in the directory and returns the generated code for the types,
+// all the types with handler methods are generated when no type names are given
+func generate(dir string, output string, typeNames []string) ([]byte, error) {
+	g := &generator{
+		fset:    token.NewFileSet(),
+		events:  make(map[string]bool),
+		types:   make(map[string]*handlerType),
+		specs:   make(map[string]*ast.TypeSpec),
+		methods: make(map[string]map[string]bool),
+
+		selected:   make(map[string]map[string]bool),
+		selectErrs: make(map[string]string),
+	}
+
+	files, err := g.parse(dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		g.collectTypes(file)
+		g.collectEvents(file)
+		g.collectSelections(file)
+	}
+	for _, file := range files {
+		g.collectHandlers(file)
+	}
+
+	types, err := g.selectTypes(typeNames)
+	if err != nil {
+		return nil, err
+	}
+	return g.render(types)
+}
+
+func (g *generator) parse(dir string, output string) ([]*ast.File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == filepath.Base(output) {
+			continue
+		}
+
+		file, err := parser.ParseFile(g.fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if g.pkgName == "" {
+			g.pkgName = file.Name.Name
+		}
+		if file.Name.Name == g.pkgName {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no go files found in %s", dir)
+	}
+	return files, nil
+}
+
+// collectTypes collects the local type declarations and the names of their methods
+func (g *generator) collectTypes(file *ast.File) {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					g.specs[ts.Name.Name] = ts
+				}
+			}
+		case *ast.FuncDecl:
+			if recv, _, ok := receiverType(decl); ok {
+				if g.methods[recv] == nil {
+					g.methods[recv] = make(map[string]bool)
+				}
+				g.methods[recv][decl.Name.Name] = true
+			}
+		}
+	}
+}
+
+// embeddedField is a type embedded in a struct, directly or through other embedded structs
+type embeddedField struct {
+	name string
+	// pointer is set when the type or a type on its path is embedded as a pointer
+	pointer bool
+	// unknown is set for a type the generator cannot look into, an imported, interface, alias or generic type
+	unknown bool
+}
+
+// embedded returns the types embedded in the local struct type by depth, the types of a depth promote their methods
+// unless a type of a lower depth has a method with the same name
+func (g *generator) embedded(name string) [][]embeddedField {
+	var levels [][]embeddedField
+	seen := map[string]bool{name: true}
+	current := []embeddedField{{name: name}}
+	for len(current) > 0 {
+		var next []embeddedField
+		for _, f := range current {
+			if f.unknown {
+				continue
+			}
+			spec, ok := g.specs[f.name]
+			if !ok {
+				continue
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			for _, field := range st.Fields.List {
+				if len(field.Names) > 0 {
+					continue
+				}
+
+				typ, pointer := field.Type, f.pointer
+				if star, ok := typ.(*ast.StarExpr); ok {
+					typ, pointer = star.X, true
+				}
+				ident, ok := typ.(*ast.Ident)
+				if !ok || !g.inspectable(ident.Name) {
+					next = append(next, embeddedField{name: g.exprString(field.Type), unknown: true})
+					continue
+				}
+				if !seen[ident.Name] {
+					seen[ident.Name] = true
+					next = append(next, embeddedField{name: ident.Name, pointer: pointer})
+				}
+			}
+		}
+		if len(next) > 0 {
+			levels = append(levels, next)
+		}
+		current = next
+	}
+	return levels
+}
+
+// inspectable reports if the methods of the local type are known to the generator
+func (g *generator) inspectable(name string) bool {
+	spec, ok := g.specs[name]
+	if !ok || spec.Assign.IsValid() || spec.TypeParams != nil {
+		return false
+	}
+	_, isInterface := spec.Type.(*ast.InterfaceType)
+	return !isInterface
+}
+
+// collectEvents collects the local types with an EventName method
+func (g *generator) collectEvents(file *ast.File) {
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || fn.Name.Name != "EventName" || fn.Type.Params.NumFields() != 0 || fn.Type.Results.NumFields() != 1 {
+			continue
+		}
+		if name, pointer, ok := receiverType(fn); ok {
+			g.events[name] = pointer
+		}
+	}
+}
+
+// event reports if the local type implements the Event interface, and if only its pointer does. The EventName method
+// is declared on the type or promoted from an embedded event, the embedded events of the lowest depth are used like
+// the method set of Go does, more than one of them is ambiguous. The reason is set for an embedded type the generator
+// cannot look into.
+func (g *generator) event(name string) (ok bool, pointer bool, reason string) {
+	if pointer, ok := g.events[name]; ok {
+		return true, pointer, ""
+	}
+
+	for _, level := range g.embedded(name) {
+		var events []embeddedField
+		for _, f := range level {
+			if f.unknown {
+				return false, false, fmt.Sprintf("argument type %s embeds %s, the generator cannot resolve if it implements eventbus.Event", name, f.name)
+			}
+			if _, ok := g.events[f.name]; ok {
+				events = append(events, f)
+			}
+		}
+		switch len(events) {
+		case 0:
+			continue
+		case 1:
+			//a pointer receiver method is promoted to the value by an embedded pointer
+			return true, g.events[events[0].name] && !events[0].pointer, ""
+		}
+		return false, false, ""
+	}
+	return false, false, ""
+}
+
+// collectSelections collects the handler methods selected by the HandlerMethods method or the eventbus struct tags
+// of a type, the same selection the eventbus.EventHandlerResolver applies
+func (g *generator) collectSelections(file *ast.File) {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.Name != "HandlerMethods" {
+				continue
+			}
+			recv, _, ok := receiverType(decl)
+			if !ok {
+				continue
+			}
+			names, ok := methodNames(decl)
+			if !ok {
+				g.selectErrs[recv] = fmt.Sprintf("%s: method %s.HandlerMethods should return a slice literal of method names",
+					g.fset.Position(decl.Pos()), recv)
+				continue
+			}
+			g.selectMethods(recv, names)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					if field.Tag == nil {
+						continue
+					}
+					tag, err := strconv.Unquote(field.Tag.Value)
+					if err != nil {
+						continue
+					}
+					if value, ok := reflect.StructTag(tag).Lookup(handlerMethodsTag); ok {
+						g.selectMethods(ts.Name.Name, strings.Split(value, ","))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (g *generator) selectMethods(typeName string, names []string) {
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if g.selected[typeName] == nil {
+			g.selected[typeName] = make(map[string]bool)
+		}
+		g.selected[typeName][name] = true
+	}
+}
+
+// methodNames returns the method names of a HandlerMethods method returning a slice literal
+func methodNames(fn *ast.FuncDecl) ([]string, bool) {
+	if fn.Body == nil || len(fn.Body.List) != 1 {
+		return nil, false
+	}
+	ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return nil, false
+	}
+	lit, ok := ret.Results[0].(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+
+	names := make([]string, 0, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		basic, ok := elt.(*ast.BasicLit)
+		if !ok || basic.Kind != token.STRING {
+			return nil, false
+		}
+		name, err := strconv.Unquote(basic.Value)
+		if err != nil {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
+
+// collectHandlers collects the exported methods with a handler signature
+func (g *generator) collectHandlers(file *ast.File) {
+	imports := fileImports(file)
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || !fn.Name.IsExported() {
+			continue
+		}
+		recv, pointer, ok := receiverType(fn)
+		if !ok {
+			continue
+		}
+
+		method, reason := g.handlerMethod(fn, imports)
+		if reason != "" {
+			if handlername.LooksLikeHandler(fn.Name.Name) {
+				t := g.handlerType(recv)
+				t.errs = append(t.errs, methodError{method: fn.Name.Name, err: fmt.Sprintf("%s: method %s.%s is not a valid handler: %s",
+					g.fset.Position(fn.Pos()), recv, fn.Name.Name, reason)})
+			}
+			continue
+		}
+
+		t := g.handlerType(recv)
+		t.Pointer = t.Pointer || pointer
+		t.Methods = append(t.Methods, method)
+	}
+}
+
+// selectMethods returns the type with only the selected methods
+func (t *handlerType) selectMethods(selected map[string]bool) *handlerType {
+	res := &handlerType{Name: t.Name, Pointer: t.Pointer}
+	for _, m := range t.Methods {
+		if selected[m.Method] {
+			res.Methods = append(res.Methods, m)
+		}
+	}
+	for _, err := range t.errs {
+		if selected[err.method] {
+			res.errs = append(res.errs, err)
+		}
+	}
+	return res
+}
+
+func (g *generator) handlerType(name string) *handlerType {
+	t, ok := g.types[name]
+	if !ok {
+		t = &handlerType{Name: name}
+		g.types[name] = t
+	}
+	return t
+}
+
+// handlerMethod returns the handler of the method, or the reason the method is not a handler
+func (g *generator) handlerMethod(fn *ast.FuncDecl, imports map[string]string) (handlerMethod, string) {
+	method := handlerMethod{Method: fn.Name.Name}
+
+	params := fieldTypes(fn.Type.Params)
+	if len(params) > 1 && isSelector(params[0], imports, "context", "Context") {
+		method.Context = true
+		params = params[1:]
+	}
+	if len(params) < 1 || len(params) > 2 {
+		return handlerMethod{}, fmt.Sprintf("expects the arguments (event), (context, event), (event, envelope) or (context, event, envelope), has %d", len(fieldTypes(fn.Type.Params)))
+	}
+	if len(params) == 2 {
+		switch t := params[1].(type) {
+		case *ast.StarExpr:
+			if isSelector(t.X, imports, eventbusPath, "Envelope") {
+				method.Envelope = "*eventbus.Envelope"
+			}
+		default:
+			if isSelector(t, imports, eventbusPath, "Envelope") {
+				method.Envelope = "eventbus.Envelope"
+			}
+		}
+		if method.Envelope == "" {
+			return handlerMethod{}, fmt.Sprintf("argument type %s is not an eventbus.Envelope", g.exprString(params[1]))
+		}
+	}
+
+	results := fieldTypes(fn.Type.Results)
+	switch {
+	case len(results) == 0:
+		method.Results = "none"
+	case len(results) == 1 && isIdent(results[0], "error"):
+		method.Results = "error"
+	case len(results) == 2 && isEvents(results[0], imports) && isIdent(results[1], "error"):
+		method.Results = "followUps"
+	default:
+		return handlerMethod{}, "should return nothing, an error or the follow-up events and an error"
+	}
+
+	switch t := params[0].(type) {
+	case *ast.Ident:
+		ok, pointerReceiver, reason := g.event(t.Name)
+		if reason != "" {
+			return handlerMethod{}, reason
+		}
+		if !ok || pointerReceiver {
+			return handlerMethod{}, fmt.Sprintf("argument type %s does not implement eventbus.Event", t.Name)
+		}
+		method.Param = t.Name
+	case *ast.StarExpr:
+		switch x := t.X.(type) {
+		case *ast.Ident:
+			ok, _, reason := g.event(x.Name)
+			if reason != "" {
+				return handlerMethod{}, reason
+			}
+			if !ok {
+				return handlerMethod{}, fmt.Sprintf("argument type *%s does not implement eventbus.Event", x.Name)
+			}
+			method.Param, method.Value = "*"+x.Name, x.Name
+		case *ast.SelectorExpr:
+			value, err := importedType(x, imports, &method)
+			if err != "" {
+				return handlerMethod{}, err
+			}
+			method.Param, method.Value = "*"+value, value
+		default:
+			return handlerMethod{}, "unsupported argument type"
+		}
+	case *ast.SelectorExpr:
+		if isSelector(t, imports, eventbusPath, "Event") {
+			method.Param, method.Wildcard = "eventbus.Event", true
+			break
+		}
+		value, err := importedType(t, imports, &method)
+		if err != "" {
+			return handlerMethod{}, err
+		}
+		method.Param = value
+	default:
+		return handlerMethod{}, "unsupported argument type"
+	}
+	return method, ""
+}
+
+func (g *generator) exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, g.fset, expr); err != nil {
+		return "unknown"
+	}
+	return buf.String()
+}
+
+// importedType registers the import of the type on the method, the imported types are assumed to implement the
+// Event interface and checked by the compiler
+func importedType(t *ast.SelectorExpr, imports map[string]string, method *handlerMethod) (string, string) {
+	pkg, ok := t.X.(*ast.Ident)
+	if !ok {
+		return "", "unsupported argument type"
+	}
+	path, ok := imports[pkg.Name]
+	if !ok {
+		return "", fmt.Sprintf("unknown package %s", pkg.Name)
+	}
+	method.imports = map[string]string{pkg.Name: path}
+	return pkg.Name + "." + t.Sel.Name, ""
+}
+
+func (g *generator) selectTypes(names []string) ([]*handlerType, error) {
+	if len(names) == 0 {
+		for name := range g.types {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	//only the selected types are validated
+	var errs []string
+	types := make([]*handlerType, 0, len(names))
+	for _, name := range names {
+		if err := g.promotionError(name); err != "" {
+			errs = append(errs, err)
+			continue
+		}
+		t, ok := g.types[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s has no handler methods", name)
+		}
+		if err, ok := g.selectErrs[name]; ok {
+			errs = append(errs, err)
+			continue
+		}
+
+		//the methods not selected by the type are skipped, like the resolver does
+		if selected := g.selected[name]; selected != nil {
+			t = t.selectMethods(selected)
+		}
+		if len(t.errs) > 0 {
+			for _, err := range t.errs {
+				errs = append(errs, err.err)
+			}
+			continue
+		}
+		if len(t.Methods) == 0 {
+			return nil, fmt.Errorf("type %s has no handler methods", name)
+		}
+		//the methods in the order of the reflection based resolver
+		sort.Slice(t.Methods, func(i, j int) bool {
+			return t.Methods[i].Method < t.Methods[j].Method
+		})
+		types = append(types, t)
+	}
+	errs = append(errs, g.inheritErrors(types)...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("no handler methods found")
+	}
+	return types, nil
+}
+
+// promotionError returns the error for a type with handler methods promoted from an embedded type, the generator only
+// generates the methods declared on the type. Of a type selecting its handler methods only the selected methods are
+// checked.
+func (g *generator) promotionError(name string) string {
+	levels := g.embedded(name)
+	if len(levels) == 0 {
+		return ""
+	}
+	pos := g.fset.Position(g.specs[name].Pos())
+
+	if selected := g.selected[name]; selected != nil {
+		for _, method := range sortedNames(selected) {
+			if !g.methods[name][method] {
+				return fmt.Sprintf("%s: type %s selects the method %s that is not declared on the type, the promoted methods of the embedded types are not generated",
+					pos, name, method)
+			}
+		}
+		return ""
+	}
+
+	//the methods of a lower depth hide the promoted methods with the same name
+	declared := make(map[string]bool)
+	for method := range g.methods[name] {
+		declared[method] = true
+	}
+	for _, level := range levels {
+		for _, f := range level {
+			if f.unknown {
+				return fmt.Sprintf("%s: type %s embeds %s, the generator cannot look up its handler methods, select the handler methods of %s with HandlerMethods or the eventbus tag",
+					pos, name, f.name, name)
+			}
+			if method, ok := g.promotedHandler(f.name, declared); ok {
+				return fmt.Sprintf("%s: type %s embeds %s, the promoted handler method %s is not generated, declare it on %s or select the handler methods with HandlerMethods or the eventbus tag",
+					pos, name, f.name, method, name)
+			}
+		}
+		for _, f := range level {
+			for method := range g.methods[f.name] {
+				declared[method] = true
+			}
+		}
+	}
+	return ""
+}
+
+// promotedHandler returns the first handler method of the embedded type that is not hidden by a declared method,
+// the methods named like a handler count as well as they are reported by the resolver
+func (g *generator) promotedHandler(name string, declared map[string]bool) (string, bool) {
+	t, ok := g.types[name]
+	if !ok {
+		return "", false
+	}
+
+	var methods []string
+	for _, m := range t.Methods {
+		methods = append(methods, m.Method)
+	}
+	for _, err := range t.errs {
+		methods = append(methods, err.method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		if !declared[method] {
+			return method, true
+		}
+	}
+	return "", false
+}
+
+// inheritErrors returns the errors for the types with handler methods embedding a generated type, they inherit its
+// EventbusHandlers method and their own handler methods are ignored by the resolver
+func (g *generator) inheritErrors(types []*handlerType) []string {
+	generated := make(map[string]bool)
+	for _, t := range types {
+		generated[t.Name] = true
+	}
+	for name, methods := range g.methods {
+		if methods["EventbusHandlers"] {
+			generated[name] = true
+		}
+	}
+
+	var errs []string
+	for _, name := range sortedNames(g.types) {
+		if generated[name] {
+			continue
+		}
+		if embedded, ok := g.embedsGenerated(name, generated); ok {
+			errs = append(errs, fmt.Sprintf("%s: type %s embeds the generated type %s and inherits its EventbusHandlers, the handler methods of %s are ignored, generate %s as well",
+				g.fset.Position(g.specs[name].Pos()), name, embedded, name, name))
+		}
+	}
+	return errs
+}
+
+func (g *generator) embedsGenerated(name string, generated map[string]bool) (string, bool) {
+	for _, level := range g.embedded(name) {
+		for _, f := range level {
+			if !f.unknown && generated[f.name] {
+				return f.name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by eventbus-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	eventbus "` + eventbusPath + `"
+{{- range $name, $path := .Imports}}
+	{{$name}} "{{$path}}"
+{{- end}}
+)
+{{range .Types}}
+// EventbusHandlers returns the handler methods of {{.Name}} for the eventbus.EventHandlerResolver
+func (h {{if .Pointer}}*{{end}}{{.Name}}) EventbusHandlers() []eventbus.GeneratedHandler {
+	return []eventbus.GeneratedHandler{
+{{- range .Methods}}
+		{
+			Event:  reflect.TypeOf((*{{.Param}})(nil)).Elem(),
+			Method: "{{.Method}}",
+{{- if eq .Results "followUps"}}
+			FollowUps: true,
+{{- end}}
+			Handler: func(ctx context.Context, event any) ([]eventbus.Event, error) {
+{{- if .Envelope}}
+				envelope, ok := eventbus.EnvelopeFromContext(ctx)
+				if !ok {
+					envelope = eventbus.NewEnvelope(ctx, event, "")
+				}
+{{- end}}
+{{- if .Wildcard}}
+				if e, ok := event.(eventbus.Event); ok {
+					{{.Call "e"}}
+				}
+{{- else if .Value}}
+				if e, ok := event.({{.Param}}); ok {
+					{{.Call "e"}}
+				}
+{{- else}}
+				switch e := event.(type) {
+				case {{.Param}}:
+					{{.Call "e"}}
+				case *{{.Param}}:
+					if e != nil {
+						{{.Call "*e"}}
+					}
+				}
+{{- end}}
+{{- if not .Wildcard}}
+				//a convertible event type is converted, like the resolver does
+				if v := reflect.ValueOf(event); v.IsValid() {
+{{- if not .Value}}
+					if v.Kind() == reflect.Ptr {
+						v = v.Elem()
+					}
+{{- end}}
+					if t := reflect.TypeOf((*{{.Param}})(nil)).Elem(); {{if not .Value}}v.IsValid() && {{end}}v.CanConvert(t) {
+						e := v.Convert(t).Interface().({{.Param}})
+						{{.Call "e"}}
+					}
+				}
+{{- end}}
+				return nil, fmt.Errorf("%w: unable to convert %T to {{.TypeString $.Package}}", eventbus.ErrEventTypeMismatch, event)
+			},
+		},
+{{- end}}
+	}
+}
+{{end}}`))
+
+func (g *generator) render(types []*handlerType) ([]byte, error) {
+	imports := make(map[string]string)
+	for _, t := range types {
+		for _, m := range t.Methods {
+			for name, path := range m.imports {
+				if name != "eventbus" && name != "fmt" && name != "context" && name != "reflect" {
+					imports[name] = path
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	err := codeTemplate.Execute(&buf, map[string]any{
+		"Package": g.pkgName,
+		"Imports": imports,
+		"Types":   types,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+func receiverType(fn *ast.FuncDecl) (string, bool, bool) {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 {
+		return "", false, false
+	}
+	switch t := fn.Recv.List[0].Type.(type) {
+	case *ast.Ident:
+		return t.Name, false, true
+	case *ast.StarExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			return ident.Name, true, true
+		}
+	}
+	return "", false, false
+}
+
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(path)
+		if path == eventbusPath {
+			name = "eventbus"
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+// fieldTypes returns the type of every parameter or result in the field list
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, field := range fields.List {
+		for i := 0; i < len(field.Names) || i == 0; i++ {
+			types = append(types, field.Type)
+		}
+	}
+	return types
+}
+
+// isSelector reports if the expression is the type name of the package with the import path
+func isSelector(expr ast.Expr, imports map[string]string, path string, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && imports[pkg.Name] == path
+}
+
+// isEvents reports if the expression is a slice of eventbus.Event
+func isEvents(expr ast.Expr, imports map[string]string) bool {
+	slice, ok := expr.(*ast.ArrayType)
+	return ok && slice.Len == nil && isSelector(slice.Elt, imports, eventbusPath, "Event")
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateMatchesExample(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	code, err := generate(dir, "eventbus_gen.go", []string{"OrderHandler"})
+	assert.NoError(t, err)
+
+	expected, err := os.ReadFile(filepath.Join(dir, "eventbus_gen.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(code), "run go generate in %s", dir)
+}
+
+func TestGenerateInvalidHandler(t *testing.T) {
+	dir := t.TempDir()
+	src := `package invalid
+
+import "github.com/mbict/go-eventbus/v2"
+
+type Created struct{}
+
+func (*Created) EventName() eventbus.EventName { return "created" }
+
+type Handler struct{}
+
+func (Handler) HandleCreated(event Created) error { return nil }
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.go"), []byte(src), 0o644))
+
+	_, err := generate(dir, "eventbus_gen.go", nil)
+	assert.ErrorContains(t, err, "method Handler.HandleCreated is not a valid handler: argument type Created does not implement eventbus.Event")
+}
+
+func TestGenerateValidatesSelectedTypes(t *testing.T) {
+	dir := t.TempDir()
+	src := `package selected
+
+import "github.com/mbict/go-eventbus/v2"
+
+type Created struct{}
+
+func (Created) EventName() eventbus.EventName { return "created" }
+
+type Handler struct{}
+
+func (Handler) HandleCreated(event Created) error { return nil }
+
+func (Handler) HandlerName() string { return "handler" }
+
+type Other struct{}
+
+func (Other) HandleStuff(value int) error { return nil }
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "selected.go"), []byte(src), 0o644))
+
+	code, err := generate(dir, "eventbus_gen.go", []string{"Handler"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(code), "func (h Handler) EventbusHandlers()")
+	assert.NotContains(t, string(code), "Other")
+
+	_, err = generate(dir, "eventbus_gen.go", nil)
+	assert.ErrorContains(t, err, "method Other.HandleStuff is not a valid handler")
+}
+
+func TestGenerateSelectedMethods(t *testing.T) {
+	dir := t.TempDir()
+	src := `package selection
+
+import "github.com/mbict/go-eventbus/v2"
+
+type Created struct{}
+
+func (Created) EventName() eventbus.EventName { return "created" }
+
+type Handler struct{}
+
+func (Handler) HandlerMethods() []string {
+	return []string{"OnCreated"}
+}
+
+func (Handler) OnCreated(event Created) error { return nil }
+
+func (Handler) HandleCreated(event Created) error { return nil }
+
+func (Handler) HandleInvalid(value int) error { return nil }
+
+type Tagged struct {
+	_ struct{} ` + "`eventbus:\"HandleCreated\"`" + `
+}
+
+func (Tagged) HandleCreated(event Created) error { return nil }
+
+func (Tagged) Audit(event Created) error { return nil }
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "selection.go"), []byte(src), 0o644))
+
+	code, err := generate(dir, "eventbus_gen.go", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(code), `Method: "OnCreated"`)
+	assert.NotContains(t, string(code), "h.HandleInvalid")
+	assert.NotContains(t, string(code), "h.Audit")
+	assert.Contains(t, string(code), "func (h Tagged) EventbusHandlers()")
+	assert.Equal(t, 1, strings.Count(string(code), `Method: "HandleCreated"`))
+}
+
+func TestGenerateImportedEvents(t *testing.T) {
+	dir := t.TempDir()
+	src := `package imported
+
+import (
+	"github.com/mbict/go-eventbus/v2"
+	events "example.com/events"
+)
+
+type Handler struct{}
+
+func (Handler) HandleCreated(event events.Created) error { return nil }
+
+func (Handler) HandleDeleted(event *events.Deleted) error { return nil }
+
+func (Handler) HandleAny(event eventbus.Event) error { return nil }
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "imported.go"), []byte(src), 0o644))
+
+	code, err := generate(dir, "eventbus_gen.go", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(code), `events "example.com/events"`)
+	assert.Contains(t, string(code), "func (h Handler) EventbusHandlers() []eventbus.GeneratedHandler")
+	assert.Contains(t, string(code), "case *events.Created:")
+	assert.Contains(t, string(code), "event.(*events.Deleted)")
+}
+
+func TestGeneratePromotedHandlers(t *testing.T) {
+	dir := t.TempDir()
+	src := `package promoted
+
+import (
+	"sync"
+
+	"github.com/mbict/go-eventbus/v2"
+)
+
+type Created struct{}
+
+func (Created) EventName() eventbus.EventName { return "created" }
+
+type Base struct{}
+
+func (*Base) HandleCreated(event Created) error { return nil }
+
+type Outer struct {
+	*Base
+}
+
+type Shadowed struct {
+	Base
+}
+
+func (Shadowed) HandleCreated(event Created) error { return nil }
+
+type Selected struct {
+	Base
+	sync.Mutex
+}
+
+func (Selected) HandlerMethods() []string { return []string{"OnCreated"} }
+
+func (Selected) OnCreated(event Created) error { return nil }
+
+type SelectsPromoted struct {
+	Base
+}
+
+func (SelectsPromoted) HandlerMethods() []string { return []string{"HandleCreated"} }
+
+type Locked struct {
+	sync.Mutex
+}
+
+func (*Locked) HandleCreated(event Created) error { return nil }
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "promoted.go"), []byte(src), 0o644))
+
+	_, err := generate(dir, "eventbus_gen.go", []string{"Outer"})
+	assert.ErrorContains(t, err, "type Outer embeds Base, the promoted handler method HandleCreated is not generated")
+
+	_, err = generate(dir, "eventbus_gen.go", []string{"SelectsPromoted"})
+	assert.ErrorContains(t, err, "type SelectsPromoted selects the method HandleCreated that is not declared on the type")
+
+	_, err = generate(dir, "eventbus_gen.go", []string{"Locked"})
+	assert.ErrorContains(t, err, "type Locked embeds sync.Mutex, the generator cannot look up its handler methods")
+
+	code, err := generate(dir, "eventbus_gen.go", []string{"Shadowed", "Selected"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(code), "func (h Shadowed) EventbusHandlers()")
+	assert.Contains(t, string(code), `Method: "OnCreated"`)
+}
+
+func TestGenerateInheritedEventbusHandlers(t *testing.T) {
+	dir := t.TempDir()
+	src := `package inherited
+
+import "github.com/mbict/go-eventbus/v2"
+
+type Created struct{}
+
+func (Created) EventName() eventbus.EventName { return "created" }
+
+type Base struct{}
+
+func (Base) HandleCreated(event Created) error { return nil }
+
+type Outer struct {
+	Base
+}
+
+func (Outer) HandleOther(event Created) error { return nil }
+
+type Plain struct {
+	Base
+}
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "inherited.go"), []byte(src), 0o644))
+
+	_, err := generate(dir, "eventbus_gen.go", []string{"Base"})
+	assert.ErrorContains(t, err, "type Outer embeds the generated type Base and inherits its EventbusHandlers, the handler methods of Outer are ignored")
+	assert.NotContains(t, err.Error(), "Plain")
+}
+
+func TestGenerateEmbeddedEvents(t *testing.T) {
+	dir := t.TempDir()
+	src := `package embedded
+
+import (
+	"time"
+
+	"github.com/mbict/go-eventbus/v2"
+)
+
+type Base struct{}
+
+func (Base) EventName() eventbus.EventName { return "base" }
+
+type PointerBase struct{}
+
+func (*PointerBase) EventName() eventbus.EventName { return "pointer" }
+
+type Created struct {
+	Base
+}
+
+type Deleted struct {
+	*PointerBase
+}
+
+type Updated struct {
+	PointerBase
+}
+
+type Ambiguous struct {
+	Base
+	PointerBase
+}
+
+type Timed struct {
+	time.Time
+}
+
+type Handler struct{}
+
+func (Handler) HandleCreated(event Created) error { return nil }
+
+func (Handler) HandleDeleted(event Deleted) error { return nil }
+
+func (Handler) HandleUpdated(event *Updated) error { return nil }
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "embedded.go"), []byte(src), 0o644))
+
+	code, err := generate(dir, "eventbus_gen.go", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(code), "case Created:")
+	assert.Contains(t, string(code), "case Deleted:")
+	assert.Contains(t, string(code), "event.(*Updated)")
+
+	invalid := `package embedded
+
+func (Handler) HandleValueUpdated(event Updated) error { return nil }
+
+func (Handler) HandleAmbiguous(event *Ambiguous) error { return nil }
+
+func (Handler) HandleTimed(event Timed) error { return nil }
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.go"), []byte(invalid), 0o644))
+
+	_, err = generate(dir, "eventbus_gen.go", nil)
+	assert.ErrorContains(t, err, "method Handler.HandleValueUpdated is not a valid handler: argument type Updated does not implement eventbus.Event")
+	assert.ErrorContains(t, err, "method Handler.HandleAmbiguous is not a valid handler: argument type *Ambiguous does not implement eventbus.Event")
+	assert.ErrorContains(t, err, "method Handler.HandleTimed is not a valid handler: argument type Timed embeds time.Time, the generator cannot resolve if it implements eventbus.Event")
+}
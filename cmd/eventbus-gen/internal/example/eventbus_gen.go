@@ -0,0 +1,75 @@
+// Code generated by eventbus-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	eventbus "github.com/mbict/go-eventbus/v2"
+)
+
+// EventbusHandlers returns the handler methods of OrderHandler for the eventbus.EventHandlerResolver
+func (h *OrderHandler) EventbusHandlers() []eventbus.GeneratedHandler {
+	return []eventbus.GeneratedHandler{
+		{
+			Event:  reflect.TypeOf((*eventbus.Event)(nil)).Elem(),
+			Method: "HandleAll",
+			Handler: func(ctx context.Context, event any) ([]eventbus.Event, error) {
+				if e, ok := event.(eventbus.Event); ok {
+					h.HandleAll(e)
+					return nil, nil
+				}
+				return nil, fmt.Errorf("%w: unable to convert %T to eventbus.Event", eventbus.ErrEventTypeMismatch, event)
+			},
+		},
+		{
+			Event:  reflect.TypeOf((*OrderPlaced)(nil)).Elem(),
+			Method: "HandlePlaced",
+			Handler: func(ctx context.Context, event any) ([]eventbus.Event, error) {
+				switch e := event.(type) {
+				case OrderPlaced:
+					return nil, h.HandlePlaced(ctx, e)
+				case *OrderPlaced:
+					if e != nil {
+						return nil, h.HandlePlaced(ctx, *e)
+					}
+				}
+				//a convertible event type is converted, like the resolver does
+				if v := reflect.ValueOf(event); v.IsValid() {
+					if v.Kind() == reflect.Ptr {
+						v = v.Elem()
+					}
+					if t := reflect.TypeOf((*OrderPlaced)(nil)).Elem(); v.IsValid() && v.CanConvert(t) {
+						e := v.Convert(t).Interface().(OrderPlaced)
+						return nil, h.HandlePlaced(ctx, e)
+					}
+				}
+				return nil, fmt.Errorf("%w: unable to convert %T to example.OrderPlaced", eventbus.ErrEventTypeMismatch, event)
+			},
+		},
+		{
+			Event:     reflect.TypeOf((**OrderShipped)(nil)).Elem(),
+			Method:    "HandleShipped",
+			FollowUps: true,
+			Handler: func(ctx context.Context, event any) ([]eventbus.Event, error) {
+				envelope, ok := eventbus.EnvelopeFromContext(ctx)
+				if !ok {
+					envelope = eventbus.NewEnvelope(ctx, event, "")
+				}
+				if e, ok := event.(*OrderShipped); ok {
+					return h.HandleShipped(e, envelope)
+				}
+				//a convertible event type is converted, like the resolver does
+				if v := reflect.ValueOf(event); v.IsValid() {
+					if t := reflect.TypeOf((**OrderShipped)(nil)).Elem(); v.CanConvert(t) {
+						e := v.Convert(t).Interface().(*OrderShipped)
+						return h.HandleShipped(e, envelope)
+					}
+				}
+				return nil, fmt.Errorf("%w: unable to convert %T to *example.OrderShipped", eventbus.ErrEventTypeMismatch, event)
+			},
+		},
+	}
+}
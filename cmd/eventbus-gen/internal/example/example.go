@@ -0,0 +1,71 @@
+// Package example contains the handler types the generator is tested with
+package example
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mbict/go-eventbus/v2"
+)
+
+//go:generate go run github.com/mbict/go-eventbus/v2/cmd/eventbus-gen -type OrderHandler
+
+var ErrNotPlaced = errors.New("order not placed")
+
+type OrderPlaced struct {
+	OrderID string
+}
+
+func (OrderPlaced) EventName() eventbus.EventName {
+	return "order.placed"
+}
+
+type OrderShipped struct {
+	OrderID string
+}
+
+func (*OrderShipped) EventName() eventbus.EventName {
+	return "order.shipped"
+}
+
+type OrderCompleted struct {
+	OrderID string
+	// ShippedBy is the id of the event that completed the order
+	ShippedBy string
+}
+
+func (OrderCompleted) EventName() eventbus.EventName {
+	return "order.completed"
+}
+
+type OrderHandler struct {
+	Placed  []string
+	Shipped []string
+	All     []eventbus.EventName
+}
+
+func (h *OrderHandler) HandlePlaced(ctx context.Context, event OrderPlaced) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	h.Placed = append(h.Placed, event.OrderID)
+	return nil
+}
+
+func (h *OrderHandler) HandleShipped(event *OrderShipped, envelope *eventbus.Envelope) ([]eventbus.Event, error) {
+	for _, id := range h.Placed {
+		if id == event.OrderID {
+			h.Shipped = append(h.Shipped, event.OrderID)
+			return []eventbus.Event{OrderCompleted{OrderID: event.OrderID, ShippedBy: envelope.ID}}, nil
+		}
+	}
+	return nil, ErrNotPlaced
+}
+
+func (h *OrderHandler) HandleAll(event eventbus.Event) {
+	h.All = append(h.All, event.EventName())
+}
+
+func (h *OrderHandler) Reset() {
+	*h = OrderHandler{}
+}
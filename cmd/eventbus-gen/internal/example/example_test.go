@@ -0,0 +1,72 @@
+package example
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mbict/go-eventbus/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedHandlers(t *testing.T) {
+	handler := &OrderHandler{}
+	bus := eventbus.New(eventbus.WithErrorHandler(func(err error, event any) error {
+		return err
+	}))
+
+	var completed []OrderCompleted
+	bus.Subscribe(eventbus.TypedHandler(func(_ context.Context, event OrderCompleted) error {
+		completed = append(completed, event)
+		return nil
+	}), "order.completed")
+
+	sub, err := eventbus.SubscribeInstance(bus, handler)
+	assert.NoError(t, err)
+
+	shipped := &eventbus.Envelope{ID: "shipped-1", Event: &OrderShipped{OrderID: "1"}}
+	assert.NoError(t, bus.Publish(&OrderPlaced{OrderID: "1"}))
+	assert.NoError(t, bus.Publish(shipped))
+	assert.Equal(t, []eventbus.EventName{"order.placed", "order.completed", "order.shipped"}, handler.All)
+	assert.Equal(t, []OrderCompleted{{OrderID: "1", ShippedBy: "shipped-1"}}, completed)
+
+	assert.ErrorIs(t, bus.Publish(&OrderShipped{OrderID: "2"}), ErrNotPlaced)
+	assert.Equal(t, []string{"1"}, handler.Placed)
+	assert.Equal(t, []string{"1"}, handler.Shipped)
+
+	sub.Unsubscribe()
+	assert.NoError(t, bus.Publish(OrderPlaced{OrderID: "3"}))
+	assert.Equal(t, []string{"1"}, handler.Placed)
+}
+
+func TestGeneratedHandlersMatchResolver(t *testing.T) {
+	//the follow-up events of HandleShipped need the bus of SubscribeInstance
+	_, err := eventbus.EventHandlerResolver(&OrderHandler{})
+	var signatureErr *eventbus.HandlerSignatureError
+	if assert.ErrorAs(t, err, &signatureErr) {
+		assert.Equal(t, "HandleShipped", signatureErr.Method)
+	}
+
+	handler := &OrderHandler{}
+	handlers, err := eventbus.EventHandlerResolver(handler, eventbus.WithIgnoredMethods("HandleShipped"))
+	assert.NoError(t, err)
+	assert.Len(t, handlers["order.placed"], 1)
+	assert.Len(t, handlers["*"], 1)
+
+	//the method value is resolved by reflection
+	reflected, err := eventbus.EventHandlerResolver(handler.HandlePlaced)
+	assert.NoError(t, err)
+
+	//a convertible event type is converted
+	assert.NoError(t, reflected["order.placed"][0](&OrderShipped{OrderID: "1"}))
+	assert.NoError(t, handlers["order.placed"][0](&OrderShipped{OrderID: "2"}))
+	assert.Equal(t, []string{"1", "2"}, handler.Placed)
+
+	for _, h := range []eventbus.EventHandlerFunc{reflected["order.placed"][0], handlers["order.placed"][0]} {
+		err = h(OrderCompleted{})
+		assert.ErrorIs(t, err, eventbus.ErrEventTypeMismatch)
+		assert.EqualError(t, err, "event type mismatch: unable to convert example.OrderCompleted to example.OrderPlaced")
+
+		var nilEvent *OrderPlaced
+		assert.ErrorIs(t, h(nilEvent), eventbus.ErrEventTypeMismatch)
+	}
+}
@@ -0,0 +1,50 @@
+// Command eventbus-gen generates static handler registration for the handler types of a package, the generated
+// EventbusHandlers method is used by the eventbus.EventHandlerResolver and eventbus.SubscribeInstance instead of
+// resolving the handler methods by reflection.
+//
+// Only the handler methods declared on a type are generated. A type with handler methods promoted from an embedded
+// type, or embedding a type the generator cannot look into, is rejected unless it selects its handler methods with
+// HandlerMethods or the eventbus tag. A type with handler methods embedding a generated type is rejected as well, as
+// it would inherit the EventbusHandlers method of the embedded type.
+//
+// Add a go:generate directive to the package with the handler types
+//
+//	//go:generate go run github.com/mbict/go-eventbus/v2/cmd/eventbus-gen -type OrderHandler
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of the handler types, all types with handler methods when empty")
+	output := flag.String("output", "eventbus_gen.go", "output file name, relative to the package directory")
+	dir := flag.String("dir", ".", "package directory")
+	flag.Parse()
+
+	var names []string
+	if *typeNames != "" {
+		for _, name := range strings.Split(*typeNames, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+
+	code, err := generate(*dir, *output, names)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eventbus-gen: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := *output
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(*dir, path)
+	}
+	if err := os.WriteFile(path, code, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "eventbus-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
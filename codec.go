@@ -0,0 +1,79 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// EventCodec serializes events for the persistent stores
+type EventCodec interface {
+	Marshal(event any) ([]byte, error)
+	Unmarshal(name EventName, data []byte) (any, error)
+}
+
+// JSONCodec serializes events as json. To decode an event to its Go type, the type needs to be registered,
+// events of unknown types are decoded as json.RawMessage.
+type JSONCodec struct {
+	mu       sync.RWMutex
+	types    map[EventName]reflect.Type
+	resolver EventNameResolver
+}
+
+// NewJSONCodec creates a json codec with the types of the provided events registered
+func NewJSONCodec(events ...any) *JSONCodec {
+	return NewJSONCodecWithResolver(resolveEventName, events...)
+}
+
+// NewJSONCodecWithRegistry creates a json codec naming the registered types with the registry, use the registry
+// of the bus the events are published on
+func NewJSONCodecWithRegistry(registry *NameRegistry, events ...any) *JSONCodec {
+	return NewJSONCodecWithResolver(registry.Name, events...)
+}
+
+// NewJSONCodecWithResolver creates a json codec naming the registered types with the resolver
+func NewJSONCodecWithResolver(resolver EventNameResolver, events ...any) *JSONCodec {
+	c := &JSONCodec{
+		types:    make(map[EventName]reflect.Type),
+		resolver: resolver,
+	}
+	c.Register(events...)
+	return c
+}
+
+// Register registers the types of the events under their event name, a pointer event is decoded as pointer
+func (c *JSONCodec) Register(events ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, event := range events {
+		c.types[c.resolver(event)] = reflect.TypeOf(event)
+	}
+}
+
+func (c *JSONCodec) Marshal(event any) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func (c *JSONCodec) Unmarshal(name EventName, data []byte) (any, error) {
+	c.mu.RLock()
+	t, ok := c.types[name]
+	c.mu.RUnlock()
+
+	if !ok {
+		return json.RawMessage(append([]byte(nil), data...)), nil
+	}
+
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
+
+	v := reflect.New(t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
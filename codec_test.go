@@ -0,0 +1,20 @@
+package eventbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONCodecWithRegistry(t *testing.T) {
+	r := NewNameRegistry(nil)
+	assert.NoError(t, r.Register(dlqEvent{}, "orders.retry"))
+
+	codec := NewJSONCodecWithRegistry(r, dlqEvent{})
+	data, err := codec.Marshal(dlqEvent{OrderID: 1})
+	assert.NoError(t, err)
+
+	event, err := codec.Unmarshal("orders.retry", data)
+	assert.NoError(t, err)
+	assert.Equal(t, dlqEvent{OrderID: 1}, event)
+}
@@ -0,0 +1,256 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrCommandHandlerExists is returned when registering a handler for a command that already has a handler
+	ErrCommandHandlerExists = errors.New("command handler already registered")
+
+	// ErrNoCommandHandler is returned when sending a command without a handler
+	ErrNoCommandHandler = errors.New("no command handler")
+
+	// ErrInvalidCommandName is returned when registering a handler for the wildcard or a topic pattern
+	ErrInvalidCommandName = errors.New("invalid command name")
+)
+
+// CommandBus dispatches every command to exactly one handler and returns its result. A handler returns
+// its result with Reply, the first reply is the result of the command.
+type CommandBus interface {
+	// Register registers the handler for the commands, a command can only have one handler
+	Register(handler EventHandler, commands ...EventName) error
+	// Unregister removes the handlers of the commands
+	Unregister(commands ...EventName)
+	Send(command any) (any, error)
+	// SendContext calls the handler of the command and returns its result and error
+	SendContext(ctx context.Context, command any) (any, error)
+}
+
+type commandBus struct {
+	handlers          map[EventName]EventHandler
+	nameResolver      nameResolver
+	handlerMiddleware handlerMiddlewares
+	publishMiddleware publishMiddlewares
+	source            string
+	repanic           bool
+	mu                sync.RWMutex
+}
+
+func (cb *commandBus) setEventResolver(resolver nameResolver) {
+	cb.nameResolver = resolver
+}
+
+func (cb *commandBus) setRepanic(repanic bool) {
+	cb.repanic = repanic
+}
+
+func (cb *commandBus) setSource(source string) {
+	cb.source = source
+}
+
+func (cb *commandBus) addHandlerMiddleware(middleware ...HandlerMiddleware) {
+	cb.handlerMiddleware = append(cb.handlerMiddleware, middleware...)
+}
+
+func (cb *commandBus) addPublishMiddleware(middleware ...PublishMiddleware) {
+	cb.publishMiddleware = append(cb.publishMiddleware, middleware...)
+}
+
+// Register registers the handler, none of the commands is registered when one of them already has a handler
+func (cb *commandBus) Register(handler EventHandler, commands ...EventName) error {
+	if len(commands) == 0 {
+		return fmt.Errorf("%w: no command names", ErrInvalidCommandName)
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	for _, command := range commands {
+		if command == "*" || isTopicPattern(command) {
+			return fmt.Errorf("%w: %s", ErrInvalidCommandName, command)
+		}
+		if _, ok := cb.handlers[command]; ok {
+			return fmt.Errorf("%w: %s", ErrCommandHandlerExists, command)
+		}
+	}
+
+	handler = cb.handlerMiddleware.then(handler)
+	for _, command := range commands {
+		cb.handlers[command] = handler
+	}
+	return nil
+}
+
+func (cb *commandBus) Unregister(commands ...EventName) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	for _, command := range commands {
+		delete(cb.handlers, command)
+	}
+}
+
+func (cb *commandBus) Send(command any) (any, error) {
+	return cb.SendContext(context.Background(), command)
+}
+
+func (cb *commandBus) SendContext(ctx context.Context, command any) (any, error) {
+	sink := &replySink{first: true, replied: make(chan struct{})}
+	envelope := newEnvelope(ctx, command, cb.source)
+	envelope.reply = sink
+	ctx = ContextWithEnvelope(ctx, envelope)
+
+	err := cb.publishMiddleware.then(cb.dispatch)(ctx, envelope.Event)
+	if replies := sink.close(); len(replies) > 0 {
+		return replies[0], err
+	}
+	return nil, err
+}
+
+func (cb *commandBus) dispatch(ctx context.Context, command any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	name, err := cb.nameResolver.Resolve(command)
+	if err != nil {
+		return err
+	}
+
+	cb.mu.RLock()
+	handler, ok := cb.handlers[name]
+	cb.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoCommandHandler, name)
+	}
+
+	err = recoverHandler(ctx, handler, name, command)
+	if cb.repanic {
+		repanicWhenRecovered(err)
+	}
+	return err
+}
+
+// CommandOption configures a command bus
+type CommandOption func(CommandBus)
+
+// WithCommandNameResolver sets the resolver for the names of the sent commands
+func WithCommandNameResolver(resolver EventNameResolver) CommandOption {
+	return func(bus CommandBus) {
+		bus.(eventNameResolverSetter).setEventResolver(resolver)
+	}
+}
+
+// WithCommandNameRegistry resolves the command names with the registry, a command with a name claimed by another type
+// is not sent
+func WithCommandNameRegistry(registry *NameRegistry) CommandOption {
+	return func(bus CommandBus) {
+		bus.(eventNameResolverSetter).setEventResolver(registry)
+	}
+}
+
+// WithCommandRepanic panics again after a recovered handler panic, like WithRepanic does for the event bus
+func WithCommandRepanic() CommandOption {
+	return func(bus CommandBus) {
+		bus.(repanicSetter).setRepanic(true)
+	}
+}
+
+// WithCommandSource sets the source in the envelopes of the sent commands
+func WithCommandSource(source string) CommandOption {
+	return func(bus CommandBus) {
+		bus.(sourceSetter).setSource(source)
+	}
+}
+
+// WithCommandHandlerMiddleware wraps every registered handler with the middleware, the first middleware is the outermost
+func WithCommandHandlerMiddleware(middleware ...HandlerMiddleware) CommandOption {
+	return func(bus CommandBus) {
+		bus.(handlerMiddlewareSetter).addHandlerMiddleware(middleware...)
+	}
+}
+
+// WithCommandPublishMiddleware wraps every send on the bus with the middleware, the first middleware is the outermost
+func WithCommandPublishMiddleware(middleware ...PublishMiddleware) CommandOption {
+	return func(bus CommandBus) {
+		bus.(publishMiddlewareSetter).addPublishMiddleware(middleware...)
+	}
+}
+
+// NewCommandBus creates a command bus
+func NewCommandBus(options ...CommandOption) CommandBus {
+	cb := &commandBus{
+		handlers:     make(map[EventName]EventHandler),
+		nameResolver: defaultNameResolver,
+	}
+
+	for _, option := range options {
+		option(cb)
+	}
+
+	return cb
+}
+
+// RegisterCommandHandlers registers the handlers resolved from the handler by the EventHandlerResolver, the handlers
+// receive the context the command is sent with. The follow-up events are published on the unit of work in the context.
+// None of the handlers is registered when a method named Handle... is not a handler, like SubscribeInstance reports,
+// a command has more than one handler, or already has a handler on the bus.
+func RegisterCommandHandlers(bus CommandBus, handler any, options ...ResolverOption) error {
+	resolved, errs := resolveHandlers(handler, options...)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	handlers := make(map[EventName][]EventHandler)
+	for _, h := range resolved {
+		handlers[h.eventName] = append(handlers[h.eventName], h.handler.bind(nil))
+	}
+
+	for command, commandHandlers := range handlers {
+		if len(commandHandlers) > 1 {
+			return fmt.Errorf("%w: %s has %d handlers", ErrCommandHandlerExists, command, len(commandHandlers))
+		}
+	}
+
+	var registered []EventName
+	for command, commandHandlers := range handlers {
+		if err := bus.Register(commandHandlers[0], command); err != nil {
+			bus.Unregister(registered...)
+			return err
+		}
+		registered = append(registered, command)
+	}
+	return nil
+}
+
+// HandleCommand registers a typed handler for the commands of type C, the returned value is the result of the command
+func HandleCommand[C Event, R any](bus CommandBus, handler func(context.Context, C) (R, error)) error {
+	return bus.Register(TypedHandler(func(ctx context.Context, command C) error {
+		result, err := handler(ctx, command)
+		if err != nil {
+			return err
+		}
+		return Reply(ctx, result)
+	}), EventNameOf[C]())
+}
+
+// SendCommand sends the command and asserts the type of the result, ErrEventTypeMismatch is returned when
+// the result is not of type R
+func SendCommand[R any](ctx context.Context, bus CommandBus, command any) (R, error) {
+	var zero R
+	result, err := bus.SendContext(ctx, command)
+	if err != nil {
+		return zero, err
+	}
+	if result == nil {
+		return zero, nil
+	}
+
+	r, ok := result.(R)
+	if !ok {
+		return zero, fmt.Errorf("%w: expected result %s, got %T", ErrEventTypeMismatch, eventTypeOf[R](), result)
+	}
+	return r, nil
+}
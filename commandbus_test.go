@@ -0,0 +1,168 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type createOrder struct {
+	Amount int
+}
+
+func (createOrder) EventName() EventName {
+	return "order.create"
+}
+
+type cancelOrder struct{}
+
+func (cancelOrder) EventName() EventName {
+	return "order.cancel"
+}
+
+type orderCommandHandler struct {
+	cancelled bool
+}
+
+func (h *orderCommandHandler) HandleCreate(command createOrder) error {
+	if command.Amount <= 0 {
+		return errors.New("invalid amount")
+	}
+	return nil
+}
+
+func (h *orderCommandHandler) HandleCancel(command cancelOrder) error {
+	h.cancelled = true
+	return nil
+}
+
+func TestCommandBusSend(t *testing.T) {
+	bus := NewCommandBus()
+	err := HandleCommand(bus, func(ctx context.Context, command createOrder) (int, error) {
+		if command.Amount <= 0 {
+			return 0, errors.New("invalid amount")
+		}
+		return 42, nil
+	})
+	assert.NoError(t, err)
+
+	result, err := bus.Send(createOrder{Amount: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, result)
+
+	id, err := SendCommand[int](context.Background(), bus, &createOrder{Amount: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	_, err = bus.Send(createOrder{})
+	assert.EqualError(t, err, "invalid amount")
+
+	_, err = SendCommand[string](context.Background(), bus, createOrder{Amount: 10})
+	assert.ErrorIs(t, err, ErrEventTypeMismatch)
+
+	_, err = bus.Send(cancelOrder{})
+	assert.ErrorIs(t, err, ErrNoCommandHandler)
+}
+
+func TestCommandBusSingleHandler(t *testing.T) {
+	bus := NewCommandBus()
+	handler := EventHandlerFunc(func(event any) error {
+		return nil
+	})
+
+	assert.NoError(t, bus.Register(handler, "order.create"))
+	assert.ErrorIs(t, bus.Register(handler, "order.cancel", "order.create"), ErrCommandHandlerExists)
+	assert.ErrorIs(t, bus.Register(handler, "*"), ErrInvalidCommandName)
+	assert.ErrorIs(t, bus.Register(handler, "order.*"), ErrInvalidCommandName)
+	assert.ErrorIs(t, bus.Register(handler), ErrInvalidCommandName)
+
+	//the failed registration did not register the other commands
+	_, err := bus.Send(cancelOrder{})
+	assert.ErrorIs(t, err, ErrNoCommandHandler)
+
+	bus.Unregister("order.create")
+	assert.NoError(t, bus.Register(handler, "order.create"))
+}
+
+func TestRegisterCommandHandlers(t *testing.T) {
+	bus := NewCommandBus()
+	handler := &orderCommandHandler{}
+	assert.NoError(t, RegisterCommandHandlers(bus, handler))
+
+	_, err := bus.Send(cancelOrder{})
+	assert.NoError(t, err)
+	assert.True(t, handler.cancelled)
+
+	_, err = bus.Send(createOrder{})
+	assert.EqualError(t, err, "invalid amount")
+
+	//all the commands already have a handler
+	other := NewCommandBus()
+	assert.NoError(t, other.Register(EventHandlerFunc(func(event any) error { return nil }), "order.create"))
+	assert.ErrorIs(t, RegisterCommandHandlers(other, handler), ErrCommandHandlerExists)
+	_, err = other.Send(cancelOrder{})
+	assert.ErrorIs(t, err, ErrNoCommandHandler)
+}
+
+type invalidCommandHandler struct{}
+
+func (invalidCommandHandler) HandleCancel(command cancelOrder) error { return nil }
+
+func (invalidCommandHandler) HandleCreate(command string) error { return nil }
+
+func TestRegisterCommandHandlersInvalidHandler(t *testing.T) {
+	bus := NewCommandBus()
+
+	var signatureErr *HandlerSignatureError
+	err := RegisterCommandHandlers(bus, invalidCommandHandler{})
+	if assert.ErrorAs(t, err, &signatureErr) {
+		assert.Equal(t, "HandleCreate", signatureErr.Method)
+	}
+
+	//nothing is registered
+	_, err = bus.Send(cancelOrder{})
+	assert.ErrorIs(t, err, ErrNoCommandHandler)
+}
+
+func TestCommandBusOptions(t *testing.T) {
+	var calls []string
+	bus := NewCommandBus(
+		WithCommandSource("orders"),
+		WithCommandNameResolver(func(event any) string {
+			return "resolved"
+		}),
+		WithCommandPublishMiddleware(func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, event any) error {
+				calls = append(calls, "publish")
+				return next(ctx, event)
+			}
+		}),
+		WithCommandHandlerMiddleware(HandleMiddlewareFunc(func(ctx context.Context, event any, next ContextEventHandler) error {
+			calls = append(calls, "handler")
+			return next.HandleContext(ctx, event)
+		})),
+	)
+
+	assert.NoError(t, bus.Register(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		envelope, _ := EnvelopeFromContext(ctx)
+		return Reply(ctx, envelope.Source)
+	}), "resolved"))
+
+	result, err := bus.Send(cancelOrder{})
+	assert.NoError(t, err)
+	assert.Equal(t, "orders", result)
+	assert.Equal(t, []string{"publish", "handler"}, calls)
+}
+
+func TestCommandBusRecoversPanics(t *testing.T) {
+	bus := NewCommandBus()
+	assert.NoError(t, bus.Register(EventHandlerFunc(func(event any) error {
+		panic("boom")
+	}), "order.cancel"))
+
+	_, err := bus.Send(cancelOrder{})
+	var panicErr *HandlerPanicError
+	assert.ErrorAs(t, err, &panicErr)
+}
@@ -1,6 +1,7 @@
 package eventbus
 
 import (
+	"context"
 	"sync"
 )
 
@@ -9,10 +10,13 @@ type concurrentEventBus struct {
 	mu sync.RWMutex
 }
 
-func (eb *concurrentEventBus) Subscribe(handler EventHandler, events ...EventName) {
+func (eb *concurrentEventBus) Subscribe(handler EventHandler, events ...EventName) Subscription {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
-	eb.EventBus.Subscribe(handler, events...)
+	return &lockedSubscription{
+		Subscription: eb.EventBus.Subscribe(handler, events...),
+		mu:           &eb.mu,
+	}
 }
 
 func (eb *concurrentEventBus) Unsubscribe(handler EventHandler, events ...EventName) {
@@ -27,6 +31,28 @@ func (eb *concurrentEventBus) Publish(event any) error {
 	return eb.EventBus.Publish(event)
 }
 
+func (eb *concurrentEventBus) PublishContext(ctx context.Context, event any) error {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+	return eb.EventBus.PublishContext(ctx, event)
+}
+
+func (eb *concurrentEventBus) findSubscription(handlerName string) (*subscription, bool) {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+	return findSubscription(eb.EventBus, handlerName)
+}
+
+func (eb *concurrentEventBus) reportError(err error, event any) error {
+	return reportError(eb.EventBus, err, event)
+}
+
+func (eb *concurrentEventBus) eventName(event any) (EventName, error) {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+	return busEventName(eb.EventBus, event)
+}
+
 func NewConcurrent(options ...Option) EventBus {
 	return &concurrentEventBus{
 		EventBus: New(options...),
@@ -0,0 +1,223 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDeadLetterNotFound is returned when a dead letter does not exist in the queue
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	// ErrHandlerNotFound is returned when the handler of a dead letter is not subscribed on the bus
+	ErrHandlerNotFound = errors.New("handler not found")
+)
+
+// DeadLetter is an event that a handler failed to handle permanently, after all the retries are exhausted
+type DeadLetter struct {
+	ID        string
+	EventName EventName
+	Event     any
+	Envelope  *Envelope
+	// Handler is the name of the handler that failed, see HandlerName
+	Handler string
+	// Errors holds the messages of the error chain, from the outermost to the root cause
+	Errors   []string
+	Attempts int
+	FailedAt time.Time
+}
+
+// DeadLetterQueue stores the dead letters of a bus
+type DeadLetterQueue interface {
+	// Add stores the dead letter, a dead letter with the same id is replaced
+	Add(ctx context.Context, letter DeadLetter) error
+	// Get returns the dead letter or ErrDeadLetterNotFound
+	Get(ctx context.Context, id string) (DeadLetter, error)
+	// List returns all the dead letters in the order they were added
+	List(ctx context.Context) ([]DeadLetter, error)
+	// Remove removes the dead letter from the queue
+	Remove(ctx context.Context, id string) error
+	// Purge removes all the dead letters from the queue
+	Purge(ctx context.Context) error
+}
+
+type deadLetterQueueSetter interface {
+	setDeadLetterQueue(DeadLetterQueue)
+}
+
+// HandlerName returns the identity of the handler used in the dead letters. Handlers implementing a HandlerName() string
+// method, like the handlers created with NamedHandler, provide their own name. For a func the name of the func is used,
+// for other handlers the type name. As all closures created by the same func share a name, name them to redrive reliably.
+func HandlerName(handler EventHandler) string {
+	switch h := handler.(type) {
+	case interface{ HandlerName() string }:
+		return h.HandlerName()
+	case *retryHandler:
+		return HandlerName(h.handler)
+	}
+
+	if v := reflect.ValueOf(handler); v.Kind() == reflect.Func {
+		if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", handler)
+}
+
+type namedHandler struct {
+	ContextEventHandler
+	name string
+}
+
+func (h namedHandler) HandlerName() string {
+	return h.name
+}
+
+// NamedHandler gives the handler a name to identify it in the dead letters
+func NamedHandler(name string, handler EventHandler) EventHandler {
+	return namedHandler{ContextEventHandler: AsContextHandler(handler), name: name}
+}
+
+// errorChain returns the messages of the error and all the errors it wraps
+func errorChain(err error) []string {
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err.Error())
+	}
+	return chain
+}
+
+// deadLetter stores the failed event in the dead letter queue. The error is returned when there is no queue,
+// when the publisher cancelled the context, or joined with the error of the queue when it fails to store the dead letter.
+func deadLetter(ctx context.Context, queue DeadLetterQueue, sub *subscription, name EventName, event any, err error) error {
+	if queue == nil || ctx.Err() != nil {
+		return err
+	}
+
+	attempts := 1
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		attempts = retryErr.Attempts
+	}
+
+	envelope, _ := EnvelopeFromContext(ctx)
+	letter := DeadLetter{
+		ID:        newEventID(),
+		EventName: name,
+		Event:     event,
+		Envelope:  envelope,
+		Handler:   sub.name,
+		Errors:    errorChain(err),
+		Attempts:  attempts,
+		FailedAt:  time.Now(),
+	}
+
+	if queueErr := queue.Add(ctx, letter); queueErr != nil {
+		return errors.Join(err, queueErr)
+	}
+	return nil
+}
+
+// subscriptionFinder is implemented by the buses that can look up a subscription by the name of its handler
+type subscriptionFinder interface {
+	findSubscription(handlerName string) (*subscription, bool)
+}
+
+// Redrive calls the handler that failed with the event of the dead letter again, with the original envelope in the context.
+// The dead letter is removed when the handler succeeds, when it fails again the dead letter is updated and the error returned.
+func Redrive(ctx context.Context, bus EventBus, queue DeadLetterQueue, id string) error {
+	letter, err := queue.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	sub, ok := findSubscription(bus, letter.Handler)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrHandlerNotFound, letter.Handler)
+	}
+
+	if letter.Envelope != nil {
+		ctx = ContextWithEnvelope(ctx, letter.Envelope)
+	}
+	if err := recoverHandler(ctx, sub.dispatch, letter.EventName, letter.Event); err != nil {
+		letter.Attempts++
+		letter.Errors = errorChain(err)
+		letter.FailedAt = time.Now()
+		return errors.Join(err, queue.Add(ctx, letter))
+	}
+	return queue.Remove(ctx, id)
+}
+
+// MemoryDeadLetterQueue keeps the dead letters in memory
+type MemoryDeadLetterQueue struct {
+	mu      sync.RWMutex
+	letters map[string]DeadLetter
+	order   map[string]int
+	seq     int
+}
+
+func NewMemoryDeadLetterQueue() *MemoryDeadLetterQueue {
+	return &MemoryDeadLetterQueue{
+		letters: make(map[string]DeadLetter),
+		order:   make(map[string]int),
+	}
+}
+
+func (q *MemoryDeadLetterQueue) Add(_ context.Context, letter DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.letters[letter.ID]; !ok {
+		q.seq++
+		q.order[letter.ID] = q.seq
+	}
+	q.letters[letter.ID] = letter
+	return nil
+}
+
+func (q *MemoryDeadLetterQueue) Get(_ context.Context, id string) (DeadLetter, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	letter, ok := q.letters[id]
+	if !ok {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return letter, nil
+}
+
+func (q *MemoryDeadLetterQueue) List(_ context.Context) ([]DeadLetter, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	res := make([]DeadLetter, 0, len(q.letters))
+	for _, letter := range q.letters {
+		res = append(res, letter)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return q.order[res[i].ID] < q.order[res[j].ID]
+	})
+	return res, nil
+}
+
+func (q *MemoryDeadLetterQueue) Remove(_ context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.letters[id]; !ok {
+		return ErrDeadLetterNotFound
+	}
+	delete(q.letters, id)
+	delete(q.order, id)
+	return nil
+}
+
+func (q *MemoryDeadLetterQueue) Purge(_ context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.letters = make(map[string]DeadLetter)
+	q.order = make(map[string]int)
+	return nil
+}
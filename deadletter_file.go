@@ -0,0 +1,201 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileDeadLetterQueue stores the dead letters in a json file, the file is rewritten on every change.
+// The events are serialized with the codec, register the event types on the codec to redrive them.
+type FileDeadLetterQueue struct {
+	mu      sync.Mutex
+	path    string
+	codec   EventCodec
+	letters []fileDeadLetter
+}
+
+type fileDeadLetter struct {
+	ID        string          `json:"id"`
+	EventName EventName       `json:"event_name"`
+	Event     json.RawMessage `json:"event"`
+	Envelope  *Envelope       `json:"envelope,omitempty"`
+	Handler   string          `json:"handler"`
+	Errors    []string        `json:"errors"`
+	Attempts  int             `json:"attempts"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// NewFileDeadLetterQueue opens the dead letter queue stored in the file, the file is created on the first dead letter
+func NewFileDeadLetterQueue(path string, codec EventCodec) (*FileDeadLetterQueue, error) {
+	q := &FileDeadLetterQueue{
+		path:  path,
+		codec: codec,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &q.letters); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *FileDeadLetterQueue) Add(_ context.Context, letter DeadLetter) error {
+	event, err := q.codec.Marshal(letter.Event)
+	if err != nil {
+		return err
+	}
+
+	var envelope *Envelope
+	if letter.Envelope != nil {
+		e := *letter.Envelope
+		e.Event = nil
+		envelope = &e
+	}
+
+	record := fileDeadLetter{
+		ID:        letter.ID,
+		EventName: letter.EventName,
+		Event:     event,
+		Envelope:  envelope,
+		Handler:   letter.Handler,
+		Errors:    letter.Errors,
+		Attempts:  letter.Attempts,
+		FailedAt:  letter.FailedAt,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	letters := append([]fileDeadLetter(nil), q.letters...)
+	if i := q.index(letter.ID); i >= 0 {
+		letters[i] = record
+	} else {
+		letters = append(letters, record)
+	}
+	return q.write(letters)
+}
+
+func (q *FileDeadLetterQueue) Get(_ context.Context, id string) (DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.index(id)
+	if i < 0 {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return q.decode(q.letters[i])
+}
+
+func (q *FileDeadLetterQueue) List(_ context.Context) ([]DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	res := make([]DeadLetter, 0, len(q.letters))
+	for _, record := range q.letters {
+		letter, err := q.decode(record)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, letter)
+	}
+	return res, nil
+}
+
+func (q *FileDeadLetterQueue) Remove(_ context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.index(id)
+	if i < 0 {
+		return ErrDeadLetterNotFound
+	}
+	letters := append(append([]fileDeadLetter(nil), q.letters[:i]...), q.letters[i+1:]...)
+	return q.write(letters)
+}
+
+func (q *FileDeadLetterQueue) Purge(_ context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.write(nil)
+}
+
+func (q *FileDeadLetterQueue) index(id string) int {
+	for i := range q.letters {
+		if q.letters[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (q *FileDeadLetterQueue) decode(record fileDeadLetter) (DeadLetter, error) {
+	event, err := q.codec.Unmarshal(record.EventName, record.Event)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	var envelope *Envelope
+	if record.Envelope != nil {
+		e := *record.Envelope
+		e.Event = event
+		envelope = &e
+	}
+
+	return DeadLetter{
+		ID:        record.ID,
+		EventName: record.EventName,
+		Event:     event,
+		Envelope:  envelope,
+		Handler:   record.Handler,
+		Errors:    record.Errors,
+		Attempts:  record.Attempts,
+		FailedAt:  record.FailedAt,
+	}, nil
+}
+
+// write replaces the file atomically, the letters in memory are only replaced when the file is written
+func (q *FileDeadLetterQueue) write(letters []fileDeadLetter) error {
+	if letters == nil {
+		letters = []fileDeadLetter{}
+	}
+	data, err := json.Marshal(letters)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(q.path, data); err != nil {
+		return err
+	}
+
+	q.letters = letters
+	return nil
+}
+
+// writeFileAtomic replaces the file with the data, the data is written to a temporary file that is renamed
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
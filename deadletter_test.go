@@ -0,0 +1,167 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dlqEvent struct {
+	OrderID int `json:"order_id"`
+}
+
+func (dlqEvent) EventName() EventName {
+	return "order.failed"
+}
+
+func TestHandlerName(t *testing.T) {
+	assert.Equal(t, "orders", HandlerName(NamedHandler("orders", EventHandlerFunc(eventHandlerForName))))
+	assert.Equal(t, "orders", HandlerName(Retry(NamedHandler("orders", EventHandlerFunc(eventHandlerForName)), RetryPolicy{})))
+	assert.Equal(t, "github.com/mbict/go-eventbus/v2.eventHandlerForName", HandlerName(EventHandlerFunc(eventHandlerForName)))
+	assert.Equal(t, "*eventbus.blockingHandler", HandlerName(newBlockingHandler()))
+}
+
+func eventHandlerForName(event any) error {
+	return nil
+}
+
+func TestDeadLetterQueueRecordsPermanentFailures(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryDeadLetterQueue()
+	var calls atomic.Int32
+
+	bus := New(WithDeadLetterQueue(queue))
+	bus.Subscribe(Retry(NamedHandler("orders", failingHandler(5, errTemporary, &calls)), RetryPolicy{MaxAttempts: 3}), "order.failed")
+
+	event := dlqEvent{OrderID: 42}
+	assert.NoError(t, bus.PublishContext(ctx, &Envelope{ID: "event-1", Event: event}))
+
+	letters, err := queue.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "orders", letters[0].Handler)
+	assert.Equal(t, EventName("order.failed"), letters[0].EventName)
+	assert.Equal(t, event, letters[0].Event)
+	assert.Equal(t, "event-1", letters[0].Envelope.ID)
+	assert.Equal(t, 3, letters[0].Attempts)
+	assert.Equal(t, []string{"handler failed after 3 attempts: temporary error", "temporary error"}, letters[0].Errors)
+}
+
+func TestDeadLetterQueueWithAsyncBus(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryDeadLetterQueue()
+
+	bus := NewAsync(WithDeadLetterQueue(queue))
+	bus.Subscribe(NamedHandler("orders", EventHandlerFunc(func(event any) error {
+		panic("boom")
+	})), "order.failed")
+
+	assert.NoError(t, bus.PublishAsync(ctx, dlqEvent{}).Wait())
+
+	letters, err := queue.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, 1, letters[0].Attempts)
+	assert.Equal(t, "panic in handler for event order.failed: boom", letters[0].Errors[0])
+}
+
+func TestRedrive(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryDeadLetterQueue()
+	var calls atomic.Int32
+
+	bus := NewConcurrent(WithDeadLetterQueue(queue))
+	bus.Subscribe(NamedHandler("orders", failingHandler(2, errTemporary, &calls)), "order.failed")
+	assert.NoError(t, bus.Publish(dlqEvent{OrderID: 1}))
+
+	letters, _ := queue.List(ctx)
+	assert.Len(t, letters, 1)
+
+	//the handler fails again, the dead letter is updated
+	err := Redrive(ctx, bus, queue, letters[0].ID)
+	assert.ErrorIs(t, err, errTemporary)
+	letter, err := queue.Get(ctx, letters[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, letter.Attempts)
+
+	assert.NoError(t, Redrive(ctx, bus, queue, letters[0].ID))
+	_, err = queue.Get(ctx, letters[0].ID)
+	assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRedriveUnknownHandler(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryDeadLetterQueue()
+	assert.NoError(t, queue.Add(ctx, DeadLetter{ID: "1", Handler: "unknown"}))
+
+	err := Redrive(ctx, New(), queue, "1")
+
+	assert.ErrorIs(t, err, ErrHandlerNotFound)
+}
+
+func TestFileDeadLetterQueue(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "deadletters.json")
+	codec := NewJSONCodec(dlqEvent{})
+
+	queue, err := NewFileDeadLetterQueue(path, codec)
+	assert.NoError(t, err)
+
+	bus := New(WithDeadLetterQueue(queue))
+	failing := true
+	var handled []dlqEvent
+	bus.Subscribe(NamedHandler("orders", EventHandlerFunc(func(event any) error {
+		if failing {
+			return errors.New("database down")
+		}
+		handled = append(handled, event.(dlqEvent))
+		return nil
+	})), "order.failed")
+
+	assert.NoError(t, bus.PublishContext(ctx, &Envelope{ID: "event-1", CorrelationID: "request-1", Event: dlqEvent{OrderID: 1}}))
+	assert.NoError(t, bus.Publish(dlqEvent{OrderID: 2}))
+
+	//reopen the queue from the file
+	queue, err = NewFileDeadLetterQueue(path, codec)
+	assert.NoError(t, err)
+	letters, err := queue.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 2)
+	assert.Equal(t, dlqEvent{OrderID: 1}, letters[0].Event)
+	assert.Equal(t, "request-1", letters[0].Envelope.CorrelationID)
+	assert.Equal(t, dlqEvent{OrderID: 1}, letters[0].Envelope.Event)
+	assert.Equal(t, []string{"database down"}, letters[0].Errors)
+
+	failing = false
+	assert.NoError(t, Redrive(ctx, bus, queue, letters[0].ID))
+	assert.Equal(t, []dlqEvent{{OrderID: 1}}, handled)
+
+	letters, err = queue.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 1)
+
+	assert.NoError(t, queue.Purge(ctx))
+	queue, err = NewFileDeadLetterQueue(path, codec)
+	assert.NoError(t, err)
+	letters, err = queue.List(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, letters)
+}
+
+func TestJSONCodecUnknownEventType(t *testing.T) {
+	codec := NewJSONCodec(&dlqEvent{})
+
+	event, err := codec.Unmarshal("order.failed", []byte(`{"order_id":1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &dlqEvent{OrderID: 1}, event)
+
+	event, err = codec.Unmarshal("unknown", []byte(`{"order_id":1}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"order_id":1}`, string(event.(json.RawMessage)))
+}
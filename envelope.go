@@ -0,0 +1,112 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// Envelope holds the metadata of a published event. The buses create an envelope for every published event
+// and pass it to the handlers in the context, see EnvelopeFromContext. Events published with a context
+// that holds an envelope are caused by that event and take over its correlation id.
+//
+// An envelope can also be published directly to provide the metadata, the missing fields are filled in by the bus.
+// The envelope is shared by all the handlers of an event and should not be modified by them.
+type Envelope struct {
+	ID            string            `json:"id"`
+	OccurredAt    time.Time         `json:"occurred_at"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	CausationID   string            `json:"causation_id,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Source        string            `json:"source,omitempty"`
+	// Event is the published event. The persistent stores serialize it with their codec and store the envelope
+	// without it, so the event is stored once.
+	Event any `json:"event"`
+
+	// reply receives the replies when the event is published as a request
+	reply *replySink
+}
+
+type envelopeKey struct{}
+
+// EnvelopeFromContext returns the envelope of the event that is handled
+func EnvelopeFromContext(ctx context.Context) (*Envelope, bool) {
+	env, ok := ctx.Value(envelopeKey{}).(*Envelope)
+	return env, ok
+}
+
+// ContextWithEnvelope returns a context holding the envelope, events published with the context are caused by the envelope
+func ContextWithEnvelope(ctx context.Context, envelope *Envelope) context.Context {
+	return context.WithValue(ctx, envelopeKey{}, envelope)
+}
+
+// EnvelopeHandlerFunc is a handler that receives the envelope of the event instead of the bare event
+type EnvelopeHandlerFunc func(ctx context.Context, envelope *Envelope) error
+
+func (h EnvelopeHandlerFunc) Handle(event any) error {
+	return h.HandleContext(context.Background(), event)
+}
+
+func (h EnvelopeHandlerFunc) HandleContext(ctx context.Context, event any) error {
+	env, ok := EnvelopeFromContext(ctx)
+	if !ok {
+		env = newEnvelope(ctx, event, "")
+	}
+	return h(ctx, env)
+}
+
+// NewEnvelope creates the envelope for the event as a bus would on publish, the event is caused by the envelope in the
+// context. Use it to assign the metadata before the event is published, like when it is stored to publish later.
+func NewEnvelope(ctx context.Context, event any, source string) *Envelope {
+	return newEnvelope(ctx, event, source)
+}
+
+// newEnvelope creates the envelope for the event, or completes the envelope when the event is an envelope
+func newEnvelope(ctx context.Context, event any, source string) *Envelope {
+	env, ok := event.(*Envelope)
+	if !ok {
+		env = &Envelope{Event: event}
+	}
+
+	if env.ID == "" {
+		env.ID = newEventID()
+	}
+	if env.OccurredAt.IsZero() {
+		env.OccurredAt = time.Now()
+	}
+	if env.Source == "" {
+		env.Source = source
+	}
+	if parent, ok := EnvelopeFromContext(ctx); ok && parent != env && env.CausationID == "" {
+		env.CausationID = parent.ID
+		if env.CorrelationID == "" {
+			env.CorrelationID = parent.CorrelationID
+		}
+	}
+	if env.CorrelationID == "" {
+		env.CorrelationID = env.ID
+	}
+	return env
+}
+
+// withEnvelope creates the envelope for the published event and returns the context holding it together with the bare event
+func withEnvelope(ctx context.Context, event any, source string) (context.Context, any) {
+	env := newEnvelope(ctx, event, source)
+	return ContextWithEnvelope(ctx, env), env.Event
+}
+
+// newEventID generates a random (version 4) UUID
+func newEventID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+type sourceSetter interface {
+	setSource(string)
+}
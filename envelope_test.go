@@ -0,0 +1,88 @@
+package eventbus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelopeIsCreatedOnPublish(t *testing.T) {
+	var received *Envelope
+	bus := New(WithSource("orders"))
+	bus.Subscribe(EnvelopeHandlerFunc(func(ctx context.Context, envelope *Envelope) error {
+		received = envelope
+		return nil
+	}), EventA)
+
+	event := &TestEventA{}
+	before := time.Now()
+	assert.NoError(t, bus.Publish(event))
+
+	assert.NotNil(t, received)
+	assert.Len(t, received.ID, 36)
+	assert.Equal(t, received.ID, received.CorrelationID)
+	assert.Empty(t, received.CausationID)
+	assert.Equal(t, "orders", received.Source)
+	assert.False(t, received.OccurredAt.Before(before))
+	assert.Same(t, event, received.Event)
+}
+
+func TestEnvelopePropagatesCorrelationToFollowUpEvents(t *testing.T) {
+	buses := map[string]func() EventBus{
+		"sync":  func() EventBus { return New() },
+		"async": func() EventBus { return NewAsync() },
+		"channeled": func() EventBus {
+			bus, cancel := NewChanneldWith(NewConcurrent())
+			t.Cleanup(cancel)
+			return bus
+		},
+	}
+
+	for name, newBus := range buses {
+		t.Run(name, func(t *testing.T) {
+			envelopes := make(chan *Envelope, 2)
+			bus := newBus()
+			bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+				envelope, _ := EnvelopeFromContext(ctx)
+				envelopes <- envelope
+				return bus.PublishContext(ctx, &TestEventB{})
+			}), EventA)
+			bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+				envelope, _ := EnvelopeFromContext(ctx)
+				envelopes <- envelope
+				return nil
+			}), EventB)
+
+			assert.NoError(t, bus.PublishContext(context.Background(), &Envelope{
+				CorrelationID: "request-1",
+				Headers:       map[string]string{"user": "john"},
+				Event:         &TestEventA{},
+			}))
+
+			first, second := <-envelopes, <-envelopes
+			assert.IsType(t, &TestEventA{}, first.Event)
+			assert.Equal(t, "request-1", first.CorrelationID)
+			assert.Equal(t, "john", first.Headers["user"])
+			assert.IsType(t, &TestEventB{}, second.Event)
+			assert.Equal(t, "request-1", second.CorrelationID)
+			assert.Equal(t, first.ID, second.CausationID)
+			assert.NotEqual(t, first.ID, second.ID)
+		})
+	}
+}
+
+func TestPublishedEnvelopeDeliversTheBareEvent(t *testing.T) {
+	var received any
+	bus := New()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		received = event
+		return nil
+	}), EventA)
+
+	event := &TestEventA{}
+	assert.NoError(t, bus.Publish(&Envelope{ID: "event-1", Event: event}))
+
+	assert.Same(t, event, received)
+}
@@ -1,7 +1,7 @@
 package eventbus
 
 import (
-	"reflect"
+	"context"
 )
 
 type EventName = string
@@ -20,12 +20,63 @@ func (h EventHandlerFunc) Handle(event any) error {
 	return h(event)
 }
 
+// ContextEventHandler is an event handler that receives the context the event was published with.
+// Because it embeds the EventHandler it can be subscribed on every bus.
+type ContextEventHandler interface {
+	EventHandler
+	HandleContext(ctx context.Context, event any) error
+}
+
+type ContextEventHandlerFunc func(ctx context.Context, event any) error
+
+func (h ContextEventHandlerFunc) Handle(event any) error {
+	return h(context.Background(), event)
+}
+
+func (h ContextEventHandlerFunc) HandleContext(ctx context.Context, event any) error {
+	return h(ctx, event)
+}
+
+// AsContextHandler adapts a plain EventHandler to a ContextEventHandler, the context is ignored
+// by the adapted handler. Handlers that already are context aware are returned as is.
+func AsContextHandler(handler EventHandler) ContextEventHandler {
+	if h, ok := handler.(ContextEventHandler); ok {
+		return h
+	}
+	return contextHandlerAdapter{handler}
+}
+
+type contextHandlerAdapter struct {
+	EventHandler
+}
+
+func (h contextHandlerAdapter) HandleContext(_ context.Context, event any) error {
+	return h.Handle(event)
+}
+
+// handleEvent calls the handler with the context when the handler supports it
+func handleEvent(ctx context.Context, handler EventHandler, event any) error {
+	if h, ok := handler.(ContextEventHandler); ok {
+		return h.HandleContext(ctx, event)
+	}
+	return handler.Handle(event)
+}
+
 type PublishErrorHandlerFunc func(error, any) error
 
+// handle passes the error to the error handler, without an error handler the error is returned as is
+func (f PublishErrorHandlerFunc) handle(err error, event any) error {
+	if f == nil {
+		return err
+	}
+	return f(err, event)
+}
+
 type EventBus interface {
-	Subscribe(EventHandler, ...EventName)
+	Subscribe(EventHandler, ...EventName) Subscription
 	Unsubscribe(EventHandler, ...EventName)
 	Publish(any) error
+	PublishContext(context.Context, any) error
 }
 
 func Chain(handler EventHandler, wrap EventHandler) EventHandler {
@@ -37,80 +88,111 @@ func Chain(handler EventHandler, wrap EventHandler) EventHandler {
 	})
 }
 
-type eventHandlers []EventHandler
-type eventChannels map[EventName]eventHandlers
-
 type eventBus struct {
-	handlers          eventChannels
+	handlerRegistry
 	errorHandlerFunc  PublishErrorHandlerFunc
-	eventNameResolver EventNameResolver
+	eventNameResolver nameResolver
+	publishMiddleware publishMiddlewares
+	source            string
+	deadLetterQueue   DeadLetterQueue
+	eventStore        EventStore
+	repanic           bool
 }
 
-func (eb *eventBus) setEventResolver(resolver EventNameResolver) {
+func (eb *eventBus) setEventResolver(resolver nameResolver) {
 	eb.eventNameResolver = resolver
 }
 
+func (eb *eventBus) eventName(event any) (EventName, error) {
+	return eb.eventNameResolver.Resolve(event)
+}
+
 func (eb *eventBus) setErrorHandler(errorHandler PublishErrorHandlerFunc) {
 	eb.errorHandlerFunc = errorHandler
 }
 
-func (eb *eventBus) Subscribe(handler EventHandler, events ...EventName) {
-	if len(events) == 0 {
-		eb.handlers["*"] = append(eb.handlers["*"], handler)
-		return
-	}
+func (eb *eventBus) reportError(err error, event any) error {
+	return eb.handlePublishError(err, event)
+}
 
-	for _, eventType := range events {
-		eb.handlers[eventType] = append(eb.handlers[eventType], handler)
-	}
+func (eb *eventBus) setRepanic(repanic bool) {
+	eb.repanic = repanic
+}
+
+func (eb *eventBus) setSource(source string) {
+	eb.source = source
+}
+
+func (eb *eventBus) setDeadLetterQueue(queue DeadLetterQueue) {
+	eb.deadLetterQueue = queue
+}
+
+func (eb *eventBus) setEventStore(store EventStore) {
+	eb.eventStore = store
+}
+
+func (eb *eventBus) addPublishMiddleware(middleware ...PublishMiddleware) {
+	eb.publishMiddleware = append(eb.publishMiddleware, middleware...)
+}
+
+func (eb *eventBus) Subscribe(handler EventHandler, events ...EventName) Subscription {
+	return eb.subscribe(handler, events, eb.remove)
 }
 
 func (eb *eventBus) Unsubscribe(handler EventHandler, events ...EventName) {
-	if len(events) == 0 {
-		for eventType := range eb.handlers {
-			eb.handlers[eventType] = removeHandlerFromSlice(eb.handlers[eventType], handler)
-			if len(eb.handlers[eventType]) == 0 {
-				delete(eb.handlers, eventType)
-			}
-		}
-	}
+	eb.unsubscribeHandler(handler, events)
+}
 
-	for _, eventType := range events {
-		eb.handlers[eventType] = removeHandlerFromSlice(eb.handlers[eventType], handler)
-		if len(eb.handlers[eventType]) == 0 {
-			delete(eb.handlers, eventType)
-		}
-	}
+func (eb *eventBus) Publish(event any) error {
+	return eb.PublishContext(context.Background(), event)
 }
 
-func removeHandlerFromSlice(eh eventHandlers, h EventHandler) eventHandlers {
-	for i := len(eh) - 1; i >= 0; i-- {
-		if reflect.ValueOf(eh[i]) == reflect.ValueOf(h) {
-			copy(eh[i:], eh[i+1:])
-			eh[len(eh)-1] = nil
-			eh = eh[:len(eh)-1]
-		}
+func (eb *eventBus) PublishContext(ctx context.Context, event any) error {
+	ctx, event = withEnvelope(ctx, event, eb.source)
+	if len(eb.publishMiddleware) == 0 {
+		return eb.dispatch(ctx, event)
 	}
-	return eh
+	return eb.publishMiddleware.then(eb.dispatch)(ctx, event)
 }
 
-func (eb *eventBus) Publish(event any) error {
+func (eb *eventBus) dispatch(ctx context.Context, event any) error {
+	name, err := eb.eventNameResolver.Resolve(event)
+	if err != nil {
+		return err
+	}
+
+	//the event is only dispatched when it is stored
+	ctx, err = storeEvent(ctx, eb.eventStore, name, event)
+	if err != nil {
+		return err
+	}
+
 	//specific event name handler
-	if err := eb.publishEvent(event, eb.handlers[eb.eventNameResolver(event)]); err != nil {
+	if err := eb.publishEvent(ctx, name, event, eb.lookup(name)); err != nil {
+		return err
+	}
+
+	//pattern event handlers
+	if err := eb.publishEvent(ctx, name, event, eb.match(name)); err != nil {
 		return err
 	}
 
 	//catchall event handlers
-	if err := eb.publishEvent(event, eb.handlers["*"]); err != nil {
+	if err := eb.publishEvent(ctx, name, event, eb.lookup("*")); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (eb *eventBus) publishEvent(event any, handlers eventHandlers) error {
-	for _, handler := range handlers {
-		if err := handler.Handle(event); err != nil {
-			if err := eb.handlePublishError(err, event); err != nil {
+func (eb *eventBus) publishEvent(ctx context.Context, name EventName, event any, handlers eventHandlers) error {
+	for _, sub := range handlers {
+		//stop dispatching when the publisher is no longer interested
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := eb.handle(ctx, sub, name, event, 1); err != nil {
+			if err := eb.handleFailure(ctx, sub, name, event, err); err != nil {
 				return err
 			}
 		}
@@ -118,19 +200,57 @@ func (eb *eventBus) publishEvent(event any, handlers eventHandlers) error {
 	return nil
 }
 
-func (eb *eventBus) handlePublishError(err error, event any) error {
-	if eb.errorHandlerFunc == nil {
-		return err
-	} else if err := eb.errorHandlerFunc(err, event); err != nil {
-		return err
+// handle calls the handler of the subscription and retries the failed calls according to the retry policy.
+// When the context provides a retry scheduler, like in a channeled bus, the retry is scheduled instead of blocking.
+func (eb *eventBus) handle(ctx context.Context, sub *subscription, name EventName, event any, attempt int) error {
+	scheduler, ok := retrySchedulerFromContext(ctx)
+	if !ok || sub.retry == nil {
+		return retryBlocking(ctx, sub.retry, attempt, func() error {
+			return recoverHandler(ctx, sub.dispatch, name, event)
+		})
+	}
+
+	err := recoverHandler(ctx, sub.dispatch, name, event)
+	delay, retry := sub.retry.next(attempt, err)
+	if !retry {
+		return sub.retry.result(attempt, err)
+	}
+
+	scheduler.scheduleRetry(delay, func() {
+		err := ctx.Err()
+		if err == nil {
+			err = eb.handle(ctx, sub, name, event, attempt+1)
+		} else {
+			err = sub.retry.result(attempt, err)
+		}
+
+		if err != nil {
+			_ = eb.handleFailure(ctx, sub, name, event, err)
+		}
+	})
+	return nil
+}
+
+// handleFailure stores the event in the dead letter queue, or passes the error to the error handler without a queue
+func (eb *eventBus) handleFailure(ctx context.Context, sub *subscription, name EventName, event any, err error) error {
+	if err := deadLetter(ctx, eb.deadLetterQueue, sub, name, event, err); err != nil {
+		return eb.handlePublishError(err, event)
 	}
 	return nil
 }
 
+func (eb *eventBus) handlePublishError(err error, event any) error {
+	publishErr := eb.errorHandlerFunc.handle(err, event)
+	if eb.repanic {
+		repanicWhenRecovered(err)
+	}
+	return publishErr
+}
+
 func New(options ...Option) EventBus {
 	eb := &eventBus{
-		handlers:          make(eventChannels),
-		eventNameResolver: resolveEventName,
+		handlerRegistry:   newHandlerRegistry(),
+		eventNameResolver: defaultNameResolver,
 	}
 
 	for _, option := range options {
@@ -1,6 +1,7 @@
 package eventbus
 
 import (
+	"context"
 	"errors"
 	"testing"
 )
@@ -223,3 +224,99 @@ func TestEventBusReturnsErrorWhenPublishErrorHandlerFails(t *testing.T) {
 		t.Fatalf("expected event to be handled once, but is handled %d times", expectedEvent.Handled)
 	}
 }
+
+type ctxKey struct{}
+
+func TestEventBusPublishContextPassesContextToHandler(t *testing.T) {
+	var received any
+	eventHandler := ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		received = ctx.Value(ctxKey{})
+		return nil
+	})
+
+	bus := New()
+	bus.Subscribe(eventHandler, EventA)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request-1")
+	err := bus.PublishContext(ctx, &TestEventA{})
+
+	if err != nil {
+		t.Fatalf("expected nil error, but got %v", err)
+	}
+
+	if received != "request-1" {
+		t.Fatalf("expected context value to be passed to the handler, but got %v", received)
+	}
+}
+
+func TestEventBusPublishContextStopsDispatchWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancellingHandler := ContextEventHandlerFunc(func(_ context.Context, event any) error {
+		event.(*TestEventA).Handled++
+		cancel()
+		return nil
+	})
+	eventHandler := EventHandlerFunc(func(event any) error {
+		event.(*TestEventA).Handled++
+		return nil
+	})
+
+	bus := New()
+	bus.Subscribe(cancellingHandler, EventA)
+	bus.Subscribe(eventHandler, EventA)
+	bus.Subscribe(eventHandler)
+	event := &TestEventA{}
+
+	err := bus.PublishContext(ctx, event)
+
+	if err != context.Canceled {
+		t.Fatalf("expected context canceled error, but got %v", err)
+	}
+
+	if event.Handled != 1 {
+		t.Fatalf("expected event to be handled once, but is handled %d times", event.Handled)
+	}
+}
+
+func TestChanneledEventBusPublishContextSkipsCancelledEvents(t *testing.T) {
+	handled := make(chan any, 2)
+	eventHandler := EventHandlerFunc(func(event any) error {
+		handled <- event
+		return nil
+	})
+
+	bus, cancelFunc := NewChanneldWith(NewConcurrent())
+	defer cancelFunc()
+	bus.Subscribe(eventHandler, EventA)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelledEvent := &TestEventA{}
+	event := &TestEventA{}
+
+	if err := bus.PublishContext(ctx, cancelledEvent); err != nil && err != context.Canceled {
+		t.Fatalf("expected nil or context canceled error, but got %v", err)
+	}
+	if err := bus.Publish(event); err != nil {
+		t.Fatalf("expected nil error, but got %v", err)
+	}
+
+	if got := <-handled; got != event {
+		t.Fatalf("expected only the non cancelled event to be handled, but got %v", got)
+	}
+}
+
+func TestAsyncEventBusPublishContextWithCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	bus := NewAsync()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		t.Error("expected handler not to be called")
+		return nil
+	}))
+
+	if err := bus.PublishContext(ctx, &TestEventA{}); err != context.Canceled {
+		t.Fatalf("expected context canceled error, but got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// StoredEvent is an event persisted in an event store
+type StoredEvent struct {
+	// Sequence is the global position of the event in the store, starting at 1
+	Sequence  uint64
+	EventName EventName
+	Envelope  *Envelope
+}
+
+// EventStore persists the published events in order
+type EventStore interface {
+	// Append stores the event and returns its sequence number
+	Append(ctx context.Context, name EventName, envelope *Envelope) (uint64, error)
+	// Load calls the func for every stored event from the sequence number on, in order, until the func returns an error
+	Load(ctx context.Context, from uint64, fn func(StoredEvent) error) error
+}
+
+type eventStoreSetter interface {
+	setEventStore(EventStore)
+}
+
+type sequenceKey struct{}
+
+// SequenceFromContext returns the sequence number of the handled event, when it is stored in an event store
+func SequenceFromContext(ctx context.Context) (uint64, bool) {
+	seq, ok := ctx.Value(sequenceKey{}).(uint64)
+	return seq, ok
+}
+
+// storeEvent appends the event to the store and returns the context holding the sequence number
+func storeEvent(ctx context.Context, store EventStore, name EventName, event any) (context.Context, error) {
+	if store == nil {
+		return ctx, nil
+	}
+
+	envelope, ok := EnvelopeFromContext(ctx)
+	if !ok {
+		envelope = newEnvelope(ctx, event, "")
+	}
+
+	seq, err := store.Append(ctx, name, envelope)
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, sequenceKey{}, seq), nil
+}
+
+// Replay feeds the stored events from the sequence number on through the handler, with the envelope and the sequence
+// number in the context. The replay stops at the first error of the handler.
+func Replay(ctx context.Context, store EventStore, from uint64, handler EventHandler) error {
+	return store.Load(ctx, from, func(stored StoredEvent) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		handlerCtx := context.WithValue(ContextWithEnvelope(ctx, stored.Envelope), sequenceKey{}, stored.Sequence)
+		if err := recoverHandler(handlerCtx, handler, stored.EventName, stored.Envelope.Event); err != nil {
+			return fmt.Errorf("replay of event %d failed: %w", stored.Sequence, err)
+		}
+		return nil
+	})
+}
+
+// MemoryEventStore keeps the events in memory
+type MemoryEventStore struct {
+	mu     sync.RWMutex
+	events []StoredEvent
+}
+
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{}
+}
+
+func (s *MemoryEventStore) Append(_ context.Context, name EventName, envelope *Envelope) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq := uint64(len(s.events)) + 1
+	s.events = append(s.events, StoredEvent{
+		Sequence:  seq,
+		EventName: name,
+		Envelope:  envelope,
+	})
+	return seq, nil
+}
+
+func (s *MemoryEventStore) Load(ctx context.Context, from uint64, fn func(StoredEvent) error) error {
+	s.mu.RLock()
+	events := s.events
+	s.mu.RUnlock()
+
+	if from < 1 {
+		from = 1
+	}
+	for i := from - 1; i < uint64(len(events)); i++ {
+		if err := fn(events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
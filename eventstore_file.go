@@ -0,0 +1,196 @@
+package eventbus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// FileEventStore is an append-only event store, every event is a json line in the file.
+// The events are serialized with the codec, register the event types on the codec to load them as their Go type.
+type FileEventStore struct {
+	mu    sync.Mutex
+	file  *os.File
+	codec EventCodec
+	seq   uint64
+	// size is the size of the file, a failed append is truncated back to it
+	size int64
+}
+
+type fileStoredEvent struct {
+	Sequence  uint64          `json:"sequence"`
+	EventName EventName       `json:"event_name"`
+	Envelope  Envelope        `json:"envelope"`
+	Event     json.RawMessage `json:"event"`
+}
+
+// NewFileEventStore opens or creates the event store in the file
+func NewFileEventStore(path string, codec EventCodec) (*FileEventStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	//a partially written last line is truncated, the next append would otherwise continue the torn line
+	size, err := truncateTornTail(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s := &FileEventStore{
+		file:  file,
+		codec: codec,
+		size:  size,
+	}
+
+	//the last sequence number is taken from the last event in the file
+	err = s.scan(0, func(record fileStoredEvent) error {
+		s.seq = record.Sequence
+		return nil
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileEventStore) Append(_ context.Context, name EventName, envelope *Envelope) (uint64, error) {
+	event, err := s.codec.Marshal(envelope.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := fileStoredEvent{
+		Sequence:  s.seq + 1,
+		EventName: name,
+		Envelope:  *envelope,
+		Event:     event,
+	}
+	record.Envelope.Event = nil
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+	line = append(line, '\n')
+	if _, err := s.file.Write(line); err != nil {
+		return 0, s.truncate(err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return 0, s.truncate(err)
+	}
+
+	s.size += int64(len(line))
+	s.seq = record.Sequence
+	return s.seq, nil
+}
+
+func (s *FileEventStore) Load(ctx context.Context, from uint64, fn func(StoredEvent) error) error {
+	return s.scan(from, func(record fileStoredEvent) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		event, err := s.codec.Unmarshal(record.EventName, record.Event)
+		if err != nil {
+			return err
+		}
+		envelope := record.Envelope
+		envelope.Event = event
+
+		return fn(StoredEvent{
+			Sequence:  record.Sequence,
+			EventName: record.EventName,
+			Envelope:  &envelope,
+		})
+	})
+}
+
+// scan reads the records from the sequence number on, the file is opened separately so appends are not blocked
+func (s *FileEventStore) scan(from uint64, fn func(fileStoredEvent) error) error {
+	file, err := os.Open(s.file.Name())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			//a partially written last line is ignored
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var record fileStoredEvent
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		if record.Sequence < from {
+			continue
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// truncate removes the partially written line of a failed append, so the next append starts on a new line
+func (s *FileEventStore) truncate(err error) error {
+	if truncateErr := s.file.Truncate(s.size); truncateErr != nil {
+		return errors.Join(err, truncateErr)
+	}
+	return err
+}
+
+// truncateTornTail truncates the file after the last complete line and returns the size of the file
+func truncateTornTail(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 4096)
+	end := info.Size()
+	for offset := end; offset > 0; {
+		n := int64(len(buf))
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+		if _, err := file.ReadAt(buf[:n], offset); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end = offset + int64(i) + 1
+			if end == info.Size() {
+				return end, nil
+			}
+			return end, file.Truncate(end)
+		}
+	}
+	//no complete line at all
+	if end == 0 {
+		return 0, nil
+	}
+	return 0, file.Truncate(0)
+}
+
+// Close closes the file of the store
+func (s *FileEventStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,210 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingEventStore struct {
+	MemoryEventStore
+}
+
+func (s *failingEventStore) Append(context.Context, EventName, *Envelope) (uint64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func TestEventStorePersistsPublishedEvents(t *testing.T) {
+	store := NewMemoryEventStore()
+	bus := New(WithEventStore(store))
+
+	var sequences []uint64
+	bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		seq, _ := SequenceFromContext(ctx)
+		sequences = append(sequences, seq)
+		return nil
+	}), "order.failed")
+
+	assert.NoError(t, bus.Publish(dlqEvent{OrderID: 1}))
+	assert.NoError(t, bus.Publish(dlqEvent{OrderID: 2}))
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+
+	assert.Equal(t, []uint64{1, 2}, sequences)
+
+	var stored []StoredEvent
+	assert.NoError(t, store.Load(context.Background(), 0, func(e StoredEvent) error {
+		stored = append(stored, e)
+		return nil
+	}))
+	if assert.Len(t, stored, 3) {
+		assert.Equal(t, EventName("order.failed"), stored[0].EventName)
+		assert.Equal(t, dlqEvent{OrderID: 2}, stored[1].Envelope.Event)
+		assert.Equal(t, uint64(3), stored[2].Sequence)
+		assert.NotEmpty(t, stored[2].Envelope.ID)
+	}
+}
+
+func TestEventStoreFailureStopsDispatch(t *testing.T) {
+	bus := New(WithEventStore(&failingEventStore{}))
+	called := false
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		called = true
+		return nil
+	}), "order.failed")
+
+	assert.EqualError(t, bus.Publish(dlqEvent{}), "store unavailable")
+	assert.False(t, called)
+}
+
+func TestEventStoreWithAsyncBus(t *testing.T) {
+	store := NewMemoryEventStore()
+	bus := NewAsync(WithEventStore(store))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		return nil
+	}), "order.failed")
+
+	assert.NoError(t, bus.PublishAsync(context.Background(), dlqEvent{OrderID: 1}).Wait())
+
+	var count int
+	_ = store.Load(context.Background(), 0, func(StoredEvent) error {
+		count++
+		return nil
+	})
+	assert.Equal(t, 1, count)
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+	bus := New(WithEventStore(store))
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, bus.Publish(dlqEvent{OrderID: i}))
+	}
+
+	var replayed []int
+	err := Replay(ctx, store, 2, ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		envelope, ok := EnvelopeFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, event, envelope.Event)
+		replayed = append(replayed, event.(dlqEvent).OrderID)
+		return nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 4}, replayed)
+
+	err = Replay(ctx, store, 0, EventHandlerFunc(func(event any) error {
+		if event.(dlqEvent).OrderID == 3 {
+			return errTemporary
+		}
+		return nil
+	}))
+	assert.ErrorIs(t, err, errTemporary)
+	assert.EqualError(t, err, "replay of event 3 failed: "+errTemporary.Error())
+}
+
+func TestFileEventStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	store, err := NewFileEventStore(path, NewJSONCodec(dlqEvent{}))
+	assert.NoError(t, err)
+	bus := New(WithEventStore(store), WithSource("orders"))
+	assert.NoError(t, bus.Publish(dlqEvent{OrderID: 1}))
+	assert.NoError(t, bus.Publish(dlqEvent{OrderID: 2}))
+	assert.NoError(t, store.Close())
+
+	//reopening continues the sequence
+	store, err = NewFileEventStore(path, NewJSONCodec(dlqEvent{}))
+	assert.NoError(t, err)
+	defer store.Close()
+	seq, err := store.Append(ctx, "order.failed", &Envelope{ID: "3", Event: dlqEvent{OrderID: 3}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), seq)
+
+	var stored []StoredEvent
+	assert.NoError(t, store.Load(ctx, 2, func(e StoredEvent) error {
+		stored = append(stored, e)
+		return nil
+	}))
+	if assert.Len(t, stored, 2) {
+		assert.Equal(t, uint64(2), stored[0].Sequence)
+		assert.Equal(t, dlqEvent{OrderID: 2}, stored[0].Envelope.Event)
+		assert.Equal(t, "orders", stored[0].Envelope.Source)
+		assert.Equal(t, "3", stored[1].Envelope.ID)
+	}
+}
+
+func TestFileEventStoreTruncatesTornTail(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	store, err := NewFileEventStore(path, NewJSONCodec(dlqEvent{}))
+	assert.NoError(t, err)
+	_, err = store.Append(ctx, "order.failed", &Envelope{ID: "1", Event: dlqEvent{OrderID: 1}})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Close())
+
+	//a crash while appending leaves a partially written line
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"sequence":2,"event_na`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	store, err = NewFileEventStore(path, NewJSONCodec(dlqEvent{}))
+	assert.NoError(t, err)
+	seq, err := store.Append(ctx, "order.failed", &Envelope{ID: "2", Event: dlqEvent{OrderID: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), seq)
+	assert.NoError(t, store.Close())
+
+	//the store reopens after the append
+	store, err = NewFileEventStore(path, NewJSONCodec(dlqEvent{}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+
+	var ids []string
+	assert.NoError(t, store.Load(ctx, 0, func(e StoredEvent) error {
+		ids = append(ids, e.Envelope.ID)
+		return nil
+	}))
+	assert.Equal(t, []string{"1", "2"}, ids)
+}
+
+func TestFileEventStoreTruncatesFailedAppend(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	store, err := NewFileEventStore(path, NewJSONCodec(dlqEvent{}))
+	assert.NoError(t, err)
+	_, err = store.Append(ctx, "order.failed", &Envelope{ID: "1", Event: dlqEvent{OrderID: 1}})
+	assert.NoError(t, err)
+
+	//a write failing partway leaves a partial line behind
+	_, err = store.file.WriteString(`{"sequence":2,"event_na`)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, store.truncate(errTemporary), errTemporary)
+
+	_, err = store.Append(ctx, "order.failed", &Envelope{ID: "2", Event: dlqEvent{OrderID: 2}})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Close())
+
+	store, err = NewFileEventStore(path, NewJSONCodec(dlqEvent{}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+
+	var ids []string
+	assert.NoError(t, store.Load(ctx, 0, func(e StoredEvent) error {
+		ids = append(ids, e.Envelope.ID)
+		return nil
+	}))
+	assert.Equal(t, []string{"1", "2"}, ids)
+}
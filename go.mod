@@ -1,6 +1,6 @@
 module github.com/mbict/go-eventbus/v2
 
-go 1.19
+go 1.20
 
 require github.com/stretchr/testify v1.8.1
 
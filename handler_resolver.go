@@ -1,11 +1,97 @@
 package eventbus
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
+
+	"github.com/mbict/go-eventbus/v2/internal/handlername"
 )
 
+// ErrNoHandlers is returned when subscribing an instance without handler methods
+var ErrNoHandlers = errors.New("no handlers found")
+
 type MappedHandlers map[string][]EventHandlerFunc
 
+// HandlerSignatureError is returned for a method that is named like a handler, but does not have a handler signature.
+// The Method is empty for a func that does not have a handler signature.
+type HandlerSignatureError struct {
+	Type   reflect.Type
+	Method string
+	Reason string
+}
+
+func (e *HandlerSignatureError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("func %s is not a valid handler: %s", e.Type, e.Reason)
+	}
+	return fmt.Sprintf("method %s.%s is not a valid handler: %s", e.Type, e.Method, e.Reason)
+}
+
+// ErrNoFollowUpBus is returned by a handler that returns follow-up events without a bus or a unit of work in the
+// context to publish them on. SubscribeInstance publishes them on the bus.
+var ErrNoFollowUpBus = errors.New("no bus to publish the follow-up events on")
+
+// GeneratedHandler is a handler method registered by generated code, see cmd/eventbus-gen. The event type is named by
+// the name registry of the resolver, the eventbus.Event interface type registers a wildcard handler.
+// The handler returns the follow-up events of the method, FollowUps is set when the method returns them.
+type GeneratedHandler struct {
+	Event     reflect.Type
+	Method    string
+	FollowUps bool
+	Handler   func(ctx context.Context, event any) ([]Event, error)
+}
+
+// GeneratedHandlers is implemented by the types with generated handler registration. The EventHandlerResolver
+// and SubscribeInstance use the generated handlers instead of resolving the methods by reflection.
+type GeneratedHandlers interface {
+	EventbusHandlers() []GeneratedHandler
+}
+
+var (
+	eventImpl    = reflect.TypeOf((*Event)(nil)).Elem()
+	errorImpl    = reflect.TypeOf((*error)(nil)).Elem()
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	envelopeType = reflect.TypeOf(Envelope{})
+	eventsType   = reflect.TypeOf([]Event(nil))
+)
+
+// handlerCall calls a resolved handler and returns its follow-up events
+type handlerCall func(ctx context.Context, event any) ([]Event, error)
+
+// bind returns the handler publishing the follow-up events on the unit of work in the context, or on the bus.
+// The follow-up events are caused by the handled event.
+func (h handlerCall) bind(bus EventBus) ContextEventHandlerFunc {
+	return func(ctx context.Context, event any) error {
+		followUps, err := h(ctx, event)
+		if err != nil || len(followUps) == 0 {
+			return err
+		}
+
+		publisher := BusFromContext(ctx, bus)
+		if publisher == nil {
+			return ErrNoFollowUpBus
+		}
+		for _, followUp := range followUps {
+			if err := publisher.PublishContext(ctx, followUp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// resolvedHandler is a handler resolved from a function or a method
+type resolvedHandler struct {
+	eventName EventName
+	// method is the name of the method, empty for a function
+	method  string
+	handler handlerCall
+	// followUps is set for a handler returning follow-up events
+	followUps bool
+}
+
 // EventHandlerResolver tries to resolve all the compatible handler from a function
 // or exported methods by a type instance. It will return all the found handlers in a map
 // where the mapped key is the event name. When the Event interface is used as the argument type
@@ -17,66 +103,273 @@ type MappedHandlers map[string][]EventHandlerFunc
 //	or a compatible event type that implements the evenbus.Event
 //
 // func( event MyEvent ) error
-func EventHandlerResolver(handler any) (MappedHandlers, error) {
+//
+// The event argument can be preceded by a context.Context and followed by the eventbus.Envelope, or a pointer to it.
+// The handler can return nothing, an error or the follow-up events and an error
+//
+// func( ctx context.Context, event MyEvent, envelope *eventbus.Envelope ) ([]eventbus.Event, error)
+//
+// The returned handlers are called without the context of the bus, so a handler returning follow-up events has no bus
+// to publish them on and is returned as HandlerSignatureError. Use SubscribeInstance to pass the context of the bus
+// and publish the follow-up events.
+//
+// The generated handlers of a type implementing GeneratedHandlers are used as they are, without reflection.
+//
+// The event names are resolved from the argument types, by the registry of WithResolverNameRegistry a name collision
+// is returned as error.
+//
+// A type selects its handler methods by implementing HandlerMethods or listing them in an eventbus struct tag,
+// the options filter the methods further.
+func EventHandlerResolver(handler any, options ...ResolverOption) (MappedHandlers, error) {
+	handlers, errs := resolveHandlers(handler, options...)
+	if err := nameErrors(errs); err != nil {
+		return nil, err
+	}
 
-	v := reflect.ValueOf(handler)
+	if err := followUpErrors(handler, handlers); err != nil {
+		return nil, err
+	}
 
-	eventImpl := reflect.TypeOf((*Event)(nil)).Elem()
-	errorImpl := reflect.TypeOf((*error)(nil)).Elem()
+	res := make(MappedHandlers)
+	for _, h := range handlers {
+		res[h.eventName] = append(res[h.eventName], EventHandlerFunc(h.handler.bind(nil).Handle))
+	}
+	return res, nil
+}
 
-	testSuitableHandler := func(t reflect.Type) bool {
-		return t.NumIn() == 1 && t.In(0).Implements(eventImpl) &&
-			t.NumOut() == 1 && t.Out(0).Implements(errorImpl)
+// resolveHandlers returns the handlers of the function or the methods of the instance, in the order of the method names.
+// The methods named Handle... that are not a handler are returned as HandlerSignatureError, unless they are filtered.
+// A handler with an event name claimed by another type is returned as an ErrEventNameCollision.
+func resolveHandlers(handler any, options ...ResolverOption) ([]resolvedHandler, []error) {
+	config := &resolverConfig{}
+	for _, option := range options {
+		option(config)
 	}
 
-	genHandler := func(v reflect.Value) func(any) error {
-		eventType := v.Type().In(0)
-		return func(evt any) error {
-			evti := reflect.ValueOf(evt)
+	var res []resolvedHandler
+	var errs []error
 
-			//when the receiver kind is different kind than the event, and the event is a pointer
-			//we will convert the pointer to a value type
-			if v.Type().In(0).Kind() != evti.Kind() && evti.Kind() == reflect.Ptr {
-				evti = evti.Elem()
+	if generated, ok := handler.(GeneratedHandlers); ok {
+		selected := selectedMethods(handler)
+		for _, h := range generated.EventbusHandlers() {
+			if reason := config.filter(h.Method, selected); reason != "" {
+				config.skipped(h.Method, reason)
+				continue
 			}
-
-			if evti.CanConvert(eventType) {
-				out := v.Call([]reflect.Value{evti.Convert(eventType)})
-				result := out[0].Interface()
-				if result != nil {
-					return result.(error)
-				}
-				return nil
-			} else {
-				panic("unable to convert to eventType")
+			eventName, err := config.typeName(h.Event)
+			if err != nil {
+				config.skipped(h.Method, err.Error())
+				errs = append(errs, fmt.Errorf("method %T.%s: %w", handler, h.Method, err))
+				continue
 			}
+			config.registered(h.Method, eventName)
+			res = append(res, resolvedHandler{eventName: eventName, method: h.Method, handler: h.Handler, followUps: h.FollowUps})
 		}
+		return res, errs
 	}
 
-	getEventNameFromMethod := func(v reflect.Value) string {
-		if v.Type().In(0).Kind() == reflect.Interface {
-			return "*"
-		}
-		return reflect.Zero(v.Type().In(0)).Interface().(Event).EventName()
-	}
-
-	res := make(MappedHandlers)
+	v := reflect.ValueOf(handler)
 
 	//func
 	if v.Kind() == reflect.Func {
-		if testSuitableHandler(v.Type()) == true {
-			eventName := getEventNameFromMethod(v)
-			res[eventName] = append(res[eventName], genHandler(v))
+		sig, reason := parseHandlerSignature(v.Type())
+		if reason != "" {
+			return nil, []error{&HandlerSignatureError{Type: v.Type(), Reason: reason}}
 		}
+		eventName, err := config.typeName(sig.event)
+		if err != nil {
+			return nil, []error{err}
+		}
+		return []resolvedHandler{{eventName: eventName, handler: genHandler(v, sig), followUps: sig.followUps}}, nil
 	}
 
 	//methods on type
+	selected := selectedMethods(handler)
 	for i := 0; i < v.NumMethod(); i++ {
-		v := v.Method(i)
-		if testSuitableHandler(v.Type()) {
-			eventName := getEventNameFromMethod(v)
-			res[eventName] = append(res[eventName], genHandler(v))
+		method := v.Type().Method(i)
+		m := v.Method(i)
+
+		if reason := config.filter(method.Name, selected); reason != "" {
+			config.skipped(method.Name, reason)
+			continue
+		}
+
+		sig, reason := parseHandlerSignature(m.Type())
+		if reason != "" {
+			config.skipped(method.Name, reason)
+			if handlername.LooksLikeHandler(method.Name) {
+				errs = append(errs, &HandlerSignatureError{Type: v.Type(), Method: method.Name, Reason: reason})
+			}
+			continue
+		}
+		eventName, err := config.typeName(sig.event)
+		if err != nil {
+			config.skipped(method.Name, err.Error())
+			errs = append(errs, fmt.Errorf("method %s.%s: %w", v.Type(), method.Name, err))
+			continue
 		}
+		config.registered(method.Name, eventName)
+		res = append(res, resolvedHandler{eventName: eventName, method: method.Name, handler: genHandler(m, sig), followUps: sig.followUps})
 	}
-	return res, nil
+	return res, errs
+}
+
+// followUpErrors returns a HandlerSignatureError for every handler returning follow-up events
+func followUpErrors(handler any, handlers []resolvedHandler) error {
+	var errs []error
+	for _, h := range handlers {
+		if h.followUps {
+			errs = append(errs, &HandlerSignatureError{
+				Type:   reflect.TypeOf(handler),
+				Method: h.method,
+				Reason: "returns follow-up events, subscribe it with SubscribeInstance to publish them",
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// nameErrors returns the event name collisions of the errors
+func nameErrors(errs []error) error {
+	var nameErrs []error
+	for _, err := range errs {
+		if errors.Is(err, ErrEventNameCollision) {
+			nameErrs = append(nameErrs, err)
+		}
+	}
+	return errors.Join(nameErrs...)
+}
+
+// handlerSignature describes the arguments and results of a handler
+type handlerSignature struct {
+	event   reflect.Type
+	context bool
+	// envelope is the type of the envelope argument, nil without an envelope argument
+	envelope  reflect.Type
+	err       bool
+	followUps bool
+}
+
+// parseHandlerSignature returns the signature of the handler, or the reason the function is not a handler
+func parseHandlerSignature(t reflect.Type) (handlerSignature, string) {
+	var sig handlerSignature
+
+	in := 0
+	if t.NumIn() > 1 && t.In(0) == contextType {
+		sig.context = true
+		in++
+	}
+	switch n := t.NumIn() - in; {
+	case n < 1 || n > 2:
+		return sig, fmt.Sprintf("expects the arguments (event), (context, event), (event, envelope) or (context, event, envelope), has %d", t.NumIn())
+	case !t.In(in).Implements(eventImpl):
+		return sig, fmt.Sprintf("argument type %s does not implement eventbus.Event", t.In(in))
+	case n == 2 && t.In(in+1) != envelopeType && t.In(in+1) != reflect.PointerTo(envelopeType):
+		return sig, fmt.Sprintf("argument type %s is not an eventbus.Envelope", t.In(in+1))
+	case n == 2:
+		sig.envelope = t.In(in + 1)
+	}
+	sig.event = t.In(in)
+
+	switch {
+	case t.NumOut() == 0:
+	case t.NumOut() == 1 && t.Out(0).Implements(errorImpl):
+		sig.err = true
+	case t.NumOut() == 2 && t.Out(0) == eventsType && t.Out(1).Implements(errorImpl):
+		sig.err, sig.followUps = true, true
+	default:
+		return sig, "should return nothing, an error or the follow-up events and an error"
+	}
+	return sig, ""
+}
+
+func genHandler(v reflect.Value, sig handlerSignature) handlerCall {
+	eventType := sig.event
+	return func(ctx context.Context, evt any) ([]Event, error) {
+		evti := reflect.ValueOf(evt)
+
+		//when the receiver kind is different kind than the event, and the event is a pointer
+		//we will convert the pointer to a value type
+		if eventType.Kind() != evti.Kind() && evti.Kind() == reflect.Ptr {
+			evti = evti.Elem()
+		}
+
+		if !evti.IsValid() || !evti.CanConvert(eventType) {
+			return nil, fmt.Errorf("%w: unable to convert %T to %s", ErrEventTypeMismatch, evt, eventType)
+		}
+
+		args := make([]reflect.Value, 0, 3)
+		if sig.context {
+			args = append(args, reflect.ValueOf(&ctx).Elem())
+		}
+		args = append(args, evti.Convert(eventType))
+		if sig.envelope != nil {
+			envelope, ok := EnvelopeFromContext(ctx)
+			if !ok {
+				envelope = newEnvelope(ctx, evt, "")
+			}
+			if sig.envelope.Kind() == reflect.Ptr {
+				args = append(args, reflect.ValueOf(envelope))
+			} else {
+				args = append(args, reflect.ValueOf(*envelope))
+			}
+		}
+
+		out := v.Call(args)
+		if !sig.err {
+			return nil, nil
+		}
+
+		var err error
+		if result := out[len(out)-1].Interface(); result != nil {
+			err = result.(error)
+		}
+		if !sig.followUps {
+			return nil, err
+		}
+		return out[0].Interface().([]Event), err
+	}
+}
+
+// SubscribeInstance subscribes all the handlers resolved by the EventHandlerResolver from the instance under their
+// event names, the returned subscription unsubscribes all of them. The handlers receive the context of the bus and
+// their follow-up events are published on the bus, or the unit of work in the context. The handlers are named after their method for the
+// dead letters. Nothing is subscribed when a method named Handle... is not a handler, a HandlerSignatureError is
+// returned for every such method.
+func SubscribeInstance(bus EventBus, instance any, options ...ResolverOption) (Subscription, error) {
+	handlers, errs := resolveHandlers(instance, options...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(handlers) == 0 {
+		return nil, fmt.Errorf("%w: %T", ErrNoHandlers, instance)
+	}
+
+	subs := make(groupSubscription, 0, len(handlers))
+	for _, h := range handlers {
+		var handler EventHandler = h.handler.bind(bus)
+		if h.method != "" {
+			handler = NamedHandler(fmt.Sprintf("%T.%s", instance, h.method), handler)
+		}
+		subs = append(subs, bus.Subscribe(handler, h.eventName))
+	}
+	return subs, nil
+}
+
+// groupSubscription is the subscription of a group of handlers
+type groupSubscription []Subscription
+
+func (g groupSubscription) Unsubscribe() {
+	for _, sub := range g {
+		sub.Unsubscribe()
+	}
+}
+
+func (g groupSubscription) Active() bool {
+	for _, sub := range g {
+		if sub.Active() {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,174 @@
+package eventbus
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// HandlerMethods is implemented by a type that selects its handler methods, the other methods are skipped.
+// A type can also list its handler methods in an eventbus struct tag, on a blank field
+//
+//	type OrderHandler struct {
+//		_ struct{} `eventbus:"HandlePlaced,HandleShipped"`
+//	}
+type HandlerMethods interface {
+	HandlerMethods() []string
+}
+
+// handlerMethodsTag is the struct tag listing the handler methods of a type
+const handlerMethodsTag = "eventbus"
+
+// ResolverOption configures the EventHandlerResolver
+type ResolverOption func(*resolverConfig)
+
+type resolverConfig struct {
+	prefix  string
+	pattern *regexp.Regexp
+	ignored map[string]bool
+	report  *ResolverReport
+	names   *NameRegistry
+}
+
+// typeName returns the event name of the type, by the registry when there is one
+func (c *resolverConfig) typeName(t reflect.Type) (EventName, error) {
+	if c.names == nil {
+		return typeEventName(t, TypeNameStrategy), nil
+	}
+	return c.names.TypeName(t)
+}
+
+// WithMethodPrefix only resolves the methods with the name prefix, like "Handle"
+func WithMethodPrefix(prefix string) ResolverOption {
+	return func(c *resolverConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithMethodPattern only resolves the methods with a name matching the pattern
+func WithMethodPattern(pattern *regexp.Regexp) ResolverOption {
+	return func(c *resolverConfig) {
+		c.pattern = pattern
+	}
+}
+
+// WithIgnoredMethods skips the methods, even when they are a handler
+func WithIgnoredMethods(methods ...string) ResolverOption {
+	return func(c *resolverConfig) {
+		if c.ignored == nil {
+			c.ignored = make(map[string]bool)
+		}
+		for _, method := range methods {
+			c.ignored[method] = true
+		}
+	}
+}
+
+// WithResolverNameRegistry resolves the event names of the handlers with the registry, use the registry of the bus
+// the handlers are subscribed on
+func WithResolverNameRegistry(registry *NameRegistry) ResolverOption {
+	return func(c *resolverConfig) {
+		c.names = registry
+	}
+}
+
+// WithResolverReport fills the report with the registered and skipped methods
+func WithResolverReport(report *ResolverReport) ResolverOption {
+	return func(c *resolverConfig) {
+		c.report = report
+	}
+}
+
+// ResolverReport lists the methods the EventHandlerResolver registered and the methods it skipped
+type ResolverReport struct {
+	Registered []RegisteredMethod
+	Skipped    []SkippedMethod
+}
+
+// RegisteredMethod is a method registered as the handler of the event name
+type RegisteredMethod struct {
+	Method    string
+	EventName EventName
+}
+
+// SkippedMethod is a method that is not registered and the reason why
+type SkippedMethod struct {
+	Method string
+	Reason string
+}
+
+func (r *ResolverReport) String() string {
+	var b strings.Builder
+	for _, m := range r.Registered {
+		fmt.Fprintf(&b, "registered %s for %s\n", m.Method, m.EventName)
+	}
+	for _, m := range r.Skipped {
+		fmt.Fprintf(&b, "skipped %s: %s\n", m.Method, m.Reason)
+	}
+	return b.String()
+}
+
+// filter returns the reason the method is skipped, or an empty string when the method is resolved.
+// The selected methods are the methods chosen by the type, nil when the type does not select its methods.
+func (c *resolverConfig) filter(method string, selected map[string]bool) string {
+	switch {
+	case c.ignored[method]:
+		return "ignored"
+	case selected != nil && !selected[method]:
+		return "not selected by the type"
+	case c.prefix != "" && !strings.HasPrefix(method, c.prefix):
+		return fmt.Sprintf("does not have the prefix %s", c.prefix)
+	case c.pattern != nil && !c.pattern.MatchString(method):
+		return fmt.Sprintf("does not match %s", c.pattern)
+	}
+	return ""
+}
+
+func (c *resolverConfig) registered(method string, eventName EventName) {
+	if c.report != nil {
+		c.report.Registered = append(c.report.Registered, RegisteredMethod{Method: method, EventName: eventName})
+	}
+}
+
+func (c *resolverConfig) skipped(method string, reason string) {
+	if c.report != nil {
+		c.report.Skipped = append(c.report.Skipped, SkippedMethod{Method: method, Reason: reason})
+	}
+}
+
+// selectedMethods returns the handler methods selected by the HandlerMethods or the struct tags of the type,
+// nil when the type does not select its methods
+func selectedMethods(handler any) map[string]bool {
+	var names []string
+	if h, ok := handler.(HandlerMethods); ok {
+		names = h.HandlerMethods()
+	}
+
+	t := reflect.TypeOf(handler)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			tag, ok := t.Field(i).Tag.Lookup(handlerMethodsTag)
+			if !ok {
+				continue
+			}
+			for _, name := range strings.Split(tag, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	if names == nil {
+		return nil
+	}
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+	return selected
+}
@@ -0,0 +1,100 @@
+package eventbus
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type filteredHandler struct{}
+
+func (filteredHandler) HandleA(event eventA) error { return nil }
+
+func (filteredHandler) HandleB(event eventB) error { return nil }
+
+func (filteredHandler) OnA(event eventA) error { return nil }
+
+func (filteredHandler) Reset() {}
+
+func Test_ResolverMethodPrefix(t *testing.T) {
+	report := &ResolverReport{}
+	res, err := EventHandlerResolver(filteredHandler{}, WithMethodPrefix("On"), WithResolverReport(report))
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Len(t, res["eventA"], 1)
+
+	assert.Equal(t, []RegisteredMethod{{Method: "OnA", EventName: "eventA"}}, report.Registered)
+	assert.Equal(t, []SkippedMethod{
+		{Method: "HandleA", Reason: "does not have the prefix On"},
+		{Method: "HandleB", Reason: "does not have the prefix On"},
+		{Method: "Reset", Reason: "does not have the prefix On"},
+	}, report.Skipped)
+}
+
+func Test_ResolverMethodPatternAndIgnoredMethods(t *testing.T) {
+	report := &ResolverReport{}
+	res, err := EventHandlerResolver(filteredHandler{},
+		WithMethodPattern(regexp.MustCompile(`^(Handle|Reset)`)),
+		WithIgnoredMethods("HandleB"),
+		WithResolverReport(report))
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Len(t, res["eventA"], 1)
+
+	assert.Equal(t, "registered HandleA for eventA\n"+
+		"skipped HandleB: ignored\n"+
+		"skipped OnA: does not match ^(Handle|Reset)\n"+
+		"skipped Reset: expects the arguments (event), (context, event), (event, envelope) or (context, event, envelope), has 0\n", report.String())
+}
+
+type taggedHandler struct {
+	_ struct{} `eventbus:"HandleA, HandleIgnore"`
+}
+
+func (taggedHandler) HandleA(event eventA) error { return nil }
+
+func (taggedHandler) HandleB(event eventB) error { return nil }
+
+func (taggedHandler) HandleIgnore(event int) error { return nil }
+
+func Test_ResolverStructTagSelectsMethods(t *testing.T) {
+	res, err := EventHandlerResolver(taggedHandler{})
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Len(t, res["eventA"], 1)
+
+	//the selected methods are checked
+	_, err = SubscribeInstance(New(), taggedHandler{})
+	var signatureErr *HandlerSignatureError
+	if assert.ErrorAs(t, err, &signatureErr) {
+		assert.Equal(t, "HandleIgnore", signatureErr.Method)
+	}
+
+	//filtered methods are not checked
+	sub, err := SubscribeInstance(New(), taggedHandler{}, WithIgnoredMethods("HandleIgnore"))
+	assert.NoError(t, err)
+	assert.True(t, sub.Active())
+}
+
+type selectingHandler struct {
+	filteredHandler
+}
+
+func (selectingHandler) HandlerMethods() []string {
+	return []string{"HandleB", "OnA"}
+}
+
+func Test_ResolverHandlerMethodsSelectsMethods(t *testing.T) {
+	report := &ResolverReport{}
+	res, err := EventHandlerResolver(selectingHandler{}, WithResolverReport(report))
+	assert.NoError(t, err)
+	assert.Len(t, res["eventA"], 1)
+	assert.Len(t, res["eventB"], 1)
+
+	assert.Equal(t, []RegisteredMethod{
+		{Method: "HandleB", EventName: "eventB"},
+		{Method: "OnA", EventName: "eventA"},
+	}, report.Registered)
+	assert.Contains(t, report.Skipped, SkippedMethod{Method: "HandleA", Reason: "not selected by the type"})
+}
@@ -1,8 +1,10 @@
 package eventbus
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 type eventPtr struct {
@@ -150,3 +152,186 @@ func Test_RegisterHandlerWithPointerEventAndNonPointerReceiverConversion(t *test
 	assert.Len(t, called, 1)
 	assert.Equal(t, []string{"eventPtr"}, called)
 }
+
+func Test_RegisterHandlerWithFunc_unconvertible_event_returns_error(t *testing.T) {
+	h := func(event eventA) error { return nil }
+
+	res, err := EventHandlerResolver(h)
+	assert.NoError(t, err)
+
+	err = res["eventA"][0](eventPtr{})
+	assert.ErrorIs(t, err, ErrEventTypeMismatch)
+
+	err = res["eventA"][0](nil)
+	assert.ErrorIs(t, err, ErrEventTypeMismatch)
+}
+
+type validHandlerInstance struct {
+	called []string
+}
+
+func (t *validHandlerInstance) HandleA(event eventA) error {
+	t.called = append(t.called, "A:"+string(event))
+	return nil
+}
+
+func (t *validHandlerInstance) HandleB(event eventB) error {
+	t.called = append(t.called, "B:"+string(event))
+	return nil
+}
+
+func (t *validHandlerInstance) Handle(event any) error {
+	return nil
+}
+
+func Test_SubscribeInstance(t *testing.T) {
+	bus := New()
+	h := &validHandlerInstance{}
+
+	sub, err := SubscribeInstance(bus, h)
+	assert.NoError(t, err)
+	assert.True(t, sub.Active())
+
+	assert.NoError(t, bus.Publish(eventA("1")))
+	assert.NoError(t, bus.Publish(eventB("2")))
+	assert.Equal(t, []string{"A:1", "B:2"}, h.called)
+
+	found, ok := findSubscription(bus, "*eventbus.validHandlerInstance.HandleA")
+	assert.True(t, ok)
+	assert.Equal(t, []EventName{"eventA"}, found.events)
+
+	sub.Unsubscribe()
+	assert.False(t, sub.Active())
+	assert.NoError(t, bus.Publish(eventA("3")))
+	assert.Len(t, h.called, 2)
+}
+
+func Test_SubscribeInstance_invalid_handler_method(t *testing.T) {
+	bus := New()
+
+	sub, err := SubscribeInstance(bus, &testHandlerInstance{})
+	assert.Nil(t, sub)
+
+	var signatureErr *HandlerSignatureError
+	if assert.ErrorAs(t, err, &signatureErr) {
+		assert.Equal(t, "HandleIgnore", signatureErr.Method)
+	}
+	assert.EqualError(t, err, "method *eventbus.testHandlerInstance.HandleIgnore is not a valid handler: argument type int does not implement eventbus.Event")
+
+	//nothing is subscribed
+	_, ok := findSubscription(bus, "*eventbus.testHandlerInstance.HandleA")
+	assert.False(t, ok)
+}
+
+func Test_SubscribeInstance_no_handlers(t *testing.T) {
+	_, err := SubscribeInstance(New(), &struct{}{})
+	assert.ErrorIs(t, err, ErrNoHandlers)
+}
+
+func Test_SubscribeInstance_invalid_func(t *testing.T) {
+	_, err := SubscribeInstance(New(), func(event int) error { return nil })
+
+	var signatureErr *HandlerSignatureError
+	assert.ErrorAs(t, err, &signatureErr)
+	assert.EqualError(t, err, "func func(int) error is not a valid handler: argument type int does not implement eventbus.Event")
+
+	_, err = SubscribeInstance(New(), func() error { return nil })
+	assert.EqualError(t, err, "func func() error is not a valid handler: expects the arguments (event), (context, event), (event, envelope) or (context, event, envelope), has 0")
+}
+
+func Test_RegisterHandlerWithContextAndEnvelope(t *testing.T) {
+	var called []string
+	h := func(ctx context.Context, event eventA, envelope Envelope) error {
+		called = append(called, ctx.Value(ctxKey{}).(string), string(event), envelope.ID)
+		return nil
+	}
+
+	bus := New()
+	sub, err := SubscribeInstance(bus, h)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	assert.NoError(t, bus.PublishContext(ctx, &Envelope{ID: "1", Event: eventA("a")}))
+	assert.Equal(t, []string{"value", "a", "1"}, called)
+}
+
+func Test_RegisterHandlerWithoutError(t *testing.T) {
+	var called []string
+	h := func(event eventA) { called = append(called, string(event)) }
+
+	res, err := EventHandlerResolver(h)
+	assert.NoError(t, err)
+	assert.Len(t, res["eventA"], 1)
+
+	assert.NoError(t, res["eventA"][0](eventA("a")))
+	assert.Equal(t, []string{"a"}, called)
+}
+
+type followUpHandler struct{}
+
+func (followUpHandler) HandleA(event eventA, envelope *Envelope) ([]Event, error) {
+	return []Event{eventB(string(event) + ":" + envelope.ID)}, nil
+}
+
+func Test_SubscribeInstance_publishes_follow_ups(t *testing.T) {
+	bus := New()
+	_, err := SubscribeInstance(bus, followUpHandler{})
+	assert.NoError(t, err)
+
+	var followUps []*Envelope
+	bus.Subscribe(EnvelopeHandlerFunc(func(ctx context.Context, envelope *Envelope) error {
+		followUps = append(followUps, envelope)
+		return nil
+	}), "eventB")
+
+	assert.NoError(t, bus.Publish(&Envelope{ID: "1", CorrelationID: "c", Event: eventA("a")}))
+	if assert.Len(t, followUps, 1) {
+		assert.Equal(t, eventB("a:1"), followUps[0].Event)
+		assert.Equal(t, "1", followUps[0].CausationID)
+		assert.Equal(t, "c", followUps[0].CorrelationID)
+	}
+
+	//the resolved handlers have no bus to publish the follow-ups on
+	_, err = EventHandlerResolver(followUpHandler{})
+	var signatureErr *HandlerSignatureError
+	if assert.ErrorAs(t, err, &signatureErr) {
+		assert.Equal(t, "HandleA", signatureErr.Method)
+	}
+	_, err = EventHandlerResolver(func(event eventA) ([]Event, error) { return nil, nil })
+	assert.ErrorAs(t, err, &signatureErr)
+}
+
+type invalidEnvelopeHandler struct{}
+
+func (invalidEnvelopeHandler) HandleA(event eventA, envelope string) error {
+	return nil
+}
+
+func Test_SubscribeInstance_invalid_envelope_argument(t *testing.T) {
+	_, err := SubscribeInstance(New(), invalidEnvelopeHandler{})
+	assert.EqualError(t, err, "method eventbus.invalidEnvelopeHandler.HandleA is not a valid handler: argument type string is not an eventbus.Envelope")
+}
+
+type namedHandlerInstance struct {
+	validHandlerInstance
+}
+
+func (namedHandlerInstance) HandlerName() string {
+	return "named"
+}
+
+func (namedHandlerInstance) Handlers() int {
+	return 0
+}
+
+func Test_SubscribeInstance_with_handler_name(t *testing.T) {
+	bus := New()
+	h := &namedHandlerInstance{}
+
+	_, err := SubscribeInstance(bus, h)
+	assert.NoError(t, err)
+
+	assert.NoError(t, bus.Publish(eventA("1")))
+	assert.Equal(t, []string{"A:1"}, h.called)
+}
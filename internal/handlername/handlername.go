@@ -0,0 +1,27 @@
+// Package handlername holds the handler method naming rules shared by the eventbus resolver and eventbus-gen.
+package handlername
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LooksLikeHandler reports if the method name is a handler name, Handle followed by an uppercase letter.
+// The methods of the EventHandler interfaces and the marker methods of the eventbus package are excluded.
+func LooksLikeHandler(name string) bool {
+	rest, ok := strings.CutPrefix(name, "Handle")
+	if !ok || markerMethods[name] {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return unicode.IsUpper(r)
+}
+
+// markerMethods are the methods the eventbus package looks up on a handler that are not handlers themselves
+var markerMethods = map[string]bool{
+	"HandleContext":    true,
+	"HandlerName":      true,
+	"HandlerMethods":   true,
+	"EventbusHandlers": true,
+}
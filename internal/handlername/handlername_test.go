@@ -0,0 +1,16 @@
+package handlername
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLooksLikeHandler(t *testing.T) {
+	assert.True(t, LooksLikeHandler("HandleOrder"))
+	assert.False(t, LooksLikeHandler("Handle"))
+	assert.False(t, LooksLikeHandler("HandleContext"))
+	assert.False(t, LooksLikeHandler("Handler"))
+	assert.False(t, LooksLikeHandler("HandlerName"))
+	assert.False(t, LooksLikeHandler("Handled"))
+}
@@ -0,0 +1,75 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned when an event is published on a closed bus
+var ErrClosed = errors.New("event bus closed")
+
+// Closer is implemented by the buses that handle events in the background
+type Closer interface {
+	// Drain waits until all the queued and in-flight events are handled or the context is done.
+	Drain(ctx context.Context) error
+
+	// Close stops accepting new events and drains the bus. When the context is done before the bus is drained,
+	// the number of events that are abandoned is returned together with the context error.
+	Close(ctx context.Context) (abandoned int, err error)
+}
+
+// ClosableEventBus is an event bus with a lifecycle
+type ClosableEventBus interface {
+	EventBus
+	Closer
+}
+
+// pendingTracker counts the events that are queued or in-flight
+type pendingTracker struct {
+	mu    sync.Mutex
+	count int
+	zero  chan struct{}
+}
+
+func (p *pendingTracker) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.count == 0 {
+		p.zero = make(chan struct{})
+	}
+	p.count += n
+}
+
+func (p *pendingTracker) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count--
+	if p.count == 0 {
+		close(p.zero)
+	}
+}
+
+func (p *pendingTracker) pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.count
+}
+
+// wait blocks until there are no pending events or the context is done
+func (p *pendingTracker) wait(ctx context.Context) error {
+	p.mu.Lock()
+	if p.count == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	zero := p.zero
+	p.mu.Unlock()
+
+	select {
+	case <-zero:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,112 @@
+package eventbus
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncEventBusCloseWaitsForInFlightHandlers(t *testing.T) {
+	var handled atomic.Int32
+	bus := NewAsync()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		time.Sleep(10 * time.Millisecond)
+		handled.Add(1)
+		return nil
+	}))
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, bus.Publish(&TestEventA{}))
+	}
+
+	abandoned, err := bus.Close(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, abandoned)
+	assert.Equal(t, int32(5), handled.Load())
+	assert.ErrorIs(t, bus.Publish(&TestEventA{}), ErrClosed)
+}
+
+func TestAsyncEventBusCloseReportsAbandonedEvents(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	bus := NewAsync()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		<-release
+		return nil
+	}))
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+	assert.NoError(t, bus.Publish(&TestEventB{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	abandoned, err := bus.Close(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 2, abandoned)
+}
+
+func TestChanneledEventBusCloseHandlesQueuedEvents(t *testing.T) {
+	var handled atomic.Int32
+	bus, cancelFunc := NewChanneldWith(New())
+	defer cancelFunc()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		time.Sleep(time.Millisecond)
+		handled.Add(1)
+		return nil
+	}))
+
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, bus.Publish(&TestEventA{}))
+	}
+
+	abandoned, err := bus.Close(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, abandoned)
+	assert.Equal(t, int32(20), handled.Load())
+	assert.ErrorIs(t, bus.Publish(&TestEventA{}), ErrClosed)
+}
+
+func TestChanneledEventBusCloseReportsAbandonedEvents(t *testing.T) {
+	release := make(chan struct{})
+	bus, _ := NewChanneldWith(New())
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		<-release
+		return nil
+	}))
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, bus.Publish(&TestEventA{}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	abandoned, err := bus.Close(ctx)
+	close(release)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 3, abandoned)
+}
+
+func TestChanneledEventBusDrainsWrappedAsyncBus(t *testing.T) {
+	var handled atomic.Int32
+	bus, cancelFunc := NewChanneldWith(NewAsync())
+	defer cancelFunc()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		time.Sleep(5 * time.Millisecond)
+		handled.Add(1)
+		return nil
+	}))
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, bus.Publish(&TestEventA{}))
+	}
+
+	assert.NoError(t, bus.Drain(context.Background()))
+	assert.Equal(t, int32(5), handled.Load())
+}
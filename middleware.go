@@ -0,0 +1,51 @@
+package eventbus
+
+import "context"
+
+// HandlerMiddleware wraps the handler of a subscription. To keep the publish context, the returned handler
+// should implement the ContextEventHandler, the HandleMiddlewareFunc takes care of this.
+type HandlerMiddleware func(next EventHandler) EventHandler
+
+// HandleMiddlewareFunc creates a context aware handler middleware from a function that calls the next handler
+func HandleMiddlewareFunc(fn func(ctx context.Context, event any, next ContextEventHandler) error) HandlerMiddleware {
+	return func(next EventHandler) EventHandler {
+		nextHandler := AsContextHandler(next)
+		return ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+			return fn(ctx, event, nextHandler)
+		})
+	}
+}
+
+// PublishFunc publishes an event
+type PublishFunc func(ctx context.Context, event any) error
+
+// PublishMiddleware wraps the publishing of every event on the bus
+type PublishMiddleware func(next PublishFunc) PublishFunc
+
+type handlerMiddlewares []HandlerMiddleware
+
+// then wraps the handler with the middleware, the first middleware is the outermost
+func (m handlerMiddlewares) then(handler EventHandler) EventHandler {
+	for i := len(m) - 1; i >= 0; i-- {
+		handler = m[i](handler)
+	}
+	return handler
+}
+
+type publishMiddlewares []PublishMiddleware
+
+// then wraps the publish func with the middleware, the first middleware is the outermost
+func (m publishMiddlewares) then(publish PublishFunc) PublishFunc {
+	for i := len(m) - 1; i >= 0; i-- {
+		publish = m[i](publish)
+	}
+	return publish
+}
+
+type handlerMiddlewareSetter interface {
+	addHandlerMiddleware(...HandlerMiddleware)
+}
+
+type publishMiddlewareSetter interface {
+	addPublishMiddleware(...PublishMiddleware)
+}
@@ -0,0 +1,113 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerMiddlewareOrder(t *testing.T) {
+	var calls []string
+	middleware := func(name string) HandlerMiddleware {
+		return HandleMiddlewareFunc(func(ctx context.Context, event any, next ContextEventHandler) error {
+			calls = append(calls, name+":before")
+			err := next.HandleContext(ctx, event)
+			calls = append(calls, name+":after")
+			return err
+		})
+	}
+
+	bus := New(WithHandlerMiddleware(middleware("outer"), middleware("inner")))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		calls = append(calls, "handler")
+		return nil
+	}), EventA)
+
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+	assert.Equal(t, []string{"outer:before", "inner:before", "handler", "inner:after", "outer:after"}, calls)
+}
+
+func TestHandlerMiddlewarePassesContext(t *testing.T) {
+	var received any
+	bus := New(WithHandlerMiddleware(HandleMiddlewareFunc(func(ctx context.Context, event any, next ContextEventHandler) error {
+		return next.HandleContext(context.WithValue(ctx, ctxKey{}, "from middleware"), event)
+	})))
+	bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		received = ctx.Value(ctxKey{})
+		return nil
+	}), EventA)
+
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+	assert.Equal(t, "from middleware", received)
+}
+
+func TestHandlerMiddlewareKeepsUnsubscribeByHandler(t *testing.T) {
+	event := &TestEventA{}
+	handler := EventHandlerFunc(func(event any) error {
+		event.(*TestEventA).Handled++
+		return nil
+	})
+
+	bus := New(WithHandlerMiddleware(func(next EventHandler) EventHandler {
+		return next
+	}))
+	bus.Subscribe(handler, EventA)
+	bus.Unsubscribe(handler, EventA)
+
+	assert.NoError(t, bus.Publish(event))
+	assert.Equal(t, 0, event.Handled)
+}
+
+func TestPublishMiddlewareCanRejectEvents(t *testing.T) {
+	errInvalid := errors.New("invalid event")
+	validation := func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, event any) error {
+			if _, ok := event.(*TestEventB); ok {
+				return errInvalid
+			}
+			return next(ctx, event)
+		}
+	}
+
+	buses := map[string]EventBus{
+		"sync":  New(WithPublishMiddleware(validation)),
+		"async": NewAsync(WithPublishMiddleware(validation)),
+	}
+
+	for name, bus := range buses {
+		t.Run(name, func(t *testing.T) {
+			handled := make(chan any, 2)
+			bus.Subscribe(EventHandlerFunc(func(event any) error {
+				handled <- event
+				return nil
+			}))
+
+			assert.ErrorIs(t, bus.PublishContext(context.Background(), &TestEventB{}), errInvalid)
+			assert.NoError(t, bus.PublishContext(context.Background(), &TestEventA{}))
+
+			assert.IsType(t, &TestEventA{}, <-handled)
+		})
+	}
+}
+
+func TestPublishMiddlewareOnPublishAsync(t *testing.T) {
+	var published []any
+	bus := NewAsync(WithPublishMiddleware(func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, event any) error {
+			published = append(published, event)
+			if event == 2 {
+				return nil
+			}
+			return next(ctx, event)
+		}
+	}))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		return errors.New("handler error")
+	}))
+
+	assert.Error(t, bus.PublishAsync(context.Background(), 1).Wait())
+	assert.NoError(t, bus.PublishAsync(context.Background(), 2).Wait())
+	assert.Equal(t, []any{1, 2}, published)
+}
@@ -1,13 +1,14 @@
 package eventbus
 
 type eventNameResolverSetter interface {
-	setEventResolver(EventNameResolver)
+	setEventResolver(nameResolver)
 }
 
 type errorHandlerSetter interface {
 	setErrorHandler(errorHandler PublishErrorHandlerFunc)
 }
 
+// Option configures an event bus, an option that does not apply to the bus panics
 type Option func(EventBus)
 
 func WithEventNameResolver(resolver EventNameResolver) Option {
@@ -16,8 +17,68 @@ func WithEventNameResolver(resolver EventNameResolver) Option {
 	}
 }
 
+// WithNameRegistry resolves the event names with the registry, an event with a name claimed by another type
+// is not published
+func WithNameRegistry(registry *NameRegistry) Option {
+	return func(bus EventBus) {
+		bus.(eventNameResolverSetter).setEventResolver(registry)
+	}
+}
+
 func WithErrorHandler(errorHandler PublishErrorHandlerFunc) Option {
 	return func(bus EventBus) {
 		bus.(errorHandlerSetter).setErrorHandler(errorHandler)
 	}
 }
+
+// WithRepanic panics again after a recovered handler panic is passed to the error handler, useful during development.
+// By default a panic in a handler is recovered and converted to a HandlerPanicError.
+func WithRepanic() Option {
+	return func(bus EventBus) {
+		bus.(repanicSetter).setRepanic(true)
+	}
+}
+
+// WithQueuePolicy sets the policy of a worker pool bus for when its queue is full, it panics on any other bus
+func WithQueuePolicy(policy QueuePolicy) Option {
+	return func(bus EventBus) {
+		bus.(queuePolicySetter).setQueuePolicy(policy)
+	}
+}
+
+// WithHandlerMiddleware wraps every handler subscribed on the bus with the middleware, the first middleware is the outermost
+func WithHandlerMiddleware(middleware ...HandlerMiddleware) Option {
+	return func(bus EventBus) {
+		bus.(handlerMiddlewareSetter).addHandlerMiddleware(middleware...)
+	}
+}
+
+// WithPublishMiddleware wraps every publish on the bus with the middleware, the first middleware is the outermost
+func WithPublishMiddleware(middleware ...PublishMiddleware) Option {
+	return func(bus EventBus) {
+		bus.(publishMiddlewareSetter).addPublishMiddleware(middleware...)
+	}
+}
+
+// WithSource sets the source in the envelopes of the events published on the bus
+func WithSource(source string) Option {
+	return func(bus EventBus) {
+		bus.(sourceSetter).setSource(source)
+	}
+}
+
+// WithDeadLetterQueue stores the events that a handler fails to handle, after the retries, in the dead letter queue
+// instead of passing the error to the error handler
+func WithDeadLetterQueue(queue DeadLetterQueue) Option {
+	return func(bus EventBus) {
+		bus.(deadLetterQueueSetter).setDeadLetterQueue(queue)
+	}
+}
+
+// WithEventStore appends every published event to the event store before it is dispatched to the handlers.
+// When the store fails to append the event, the event is not dispatched and the error is returned to the publisher.
+func WithEventStore(store EventStore) Option {
+	return func(bus EventBus) {
+		bus.(eventStoreSetter).setEventStore(store)
+	}
+}
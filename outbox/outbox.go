@@ -0,0 +1,150 @@
+// Package outbox implements the transactional outbox pattern for the event bus. The events are written to an outbox
+// table in the same database transaction as the state changes that caused them, a relay publishes the committed
+// events on the bus afterwards. An event is published at least once, handlers should be idempotent,
+// the envelope id of an event is stable between the deliveries.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mbict/go-eventbus/v2"
+)
+
+// PostgresSchema creates the default outbox table in Postgres
+const PostgresSchema = `CREATE TABLE IF NOT EXISTS outbox (
+	position BIGSERIAL PRIMARY KEY,
+	id TEXT NOT NULL UNIQUE,
+	event_name TEXT NOT NULL,
+	envelope TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	dispatched_at TIMESTAMPTZ
+)`
+
+// SQLiteSchema creates the default outbox table in SQLite
+const SQLiteSchema = `CREATE TABLE IF NOT EXISTS outbox (
+	position INTEGER PRIMARY KEY AUTOINCREMENT,
+	id TEXT NOT NULL UNIQUE,
+	event_name TEXT NOT NULL,
+	envelope TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	dispatched_at TIMESTAMP
+)`
+
+// Placeholder returns the bind parameter for the nth (starting at 1) argument of a query
+type Placeholder func(n int) string
+
+// Dollar is the placeholder used by Postgres, $1, $2, ...
+func Dollar(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Question is the placeholder used by SQLite and MySQL
+func Question(int) string {
+	return "?"
+}
+
+type Option func(*Outbox)
+
+// WithTable sets the name of the outbox table, the default is outbox
+func WithTable(table string) Option {
+	return func(o *Outbox) {
+		o.table = table
+	}
+}
+
+// WithPlaceholder sets the bind parameters of the database, the default is Dollar
+func WithPlaceholder(placeholder Placeholder) Option {
+	return func(o *Outbox) {
+		o.placeholder = placeholder
+	}
+}
+
+// WithEventNameResolver sets the resolver for the names of the written events
+func WithEventNameResolver(resolver eventbus.EventNameResolver) Option {
+	return func(o *Outbox) {
+		o.resolver = resolver
+	}
+}
+
+// WithNameRegistry resolves the names of the written events with the registry, an event with a name claimed by
+// another type is not written
+func WithNameRegistry(registry *eventbus.NameRegistry) Option {
+	return func(o *Outbox) {
+		o.resolver = registry
+	}
+}
+
+// WithSource sets the source in the envelopes of the written events
+func WithSource(source string) Option {
+	return func(o *Outbox) {
+		o.source = source
+	}
+}
+
+// nameResolver resolves the names of the written events, implemented by the EventNameResolver and the NameRegistry
+type nameResolver interface {
+	Resolve(event any) (eventbus.EventName, error)
+}
+
+// Outbox writes the events to the outbox table
+type Outbox struct {
+	table       string
+	placeholder Placeholder
+	resolver    nameResolver
+	codec       eventbus.EventCodec
+	source      string
+}
+
+// New creates an outbox, the events are serialized with the codec.
+// Register the event types on the codec so the relay publishes them as their Go type.
+func New(codec eventbus.EventCodec, options ...Option) *Outbox {
+	o := &Outbox{
+		table:       "outbox",
+		placeholder: Dollar,
+		resolver:    eventbus.EventNameResolver(eventbus.ResolveEventName),
+		codec:       codec,
+	}
+
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// Write adds the events to the outbox within the transaction, the events are published when the transaction is committed.
+// The envelope metadata is assigned when writing, events are caused by the envelope in the context.
+// An envelope can be written to provide the metadata.
+func (o *Outbox) Write(ctx context.Context, tx *sql.Tx, events ...any) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, event_name, envelope, payload) VALUES (%s, %s, %s, %s)",
+		o.table, o.placeholder(1), o.placeholder(2), o.placeholder(3), o.placeholder(4))
+
+	for _, event := range events {
+		envelope := eventbus.NewEnvelope(ctx, event, o.source)
+
+		payload, err := o.codec.Marshal(envelope.Event)
+		if err != nil {
+			return err
+		}
+
+		metadata := *envelope
+		metadata.Event = nil
+		envelopeData, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+
+		name, err := o.resolver.Resolve(envelope.Event)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, envelope.ID, name, string(envelopeData), string(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mbict/go-eventbus/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDriver is a database/sql driver holding a single outbox table in memory, it only understands the queries of the outbox
+type stubDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*stubTable
+}
+
+type stubTable struct {
+	mu   sync.Mutex
+	rows []stubRow
+}
+
+type stubRow struct {
+	position   int64
+	values     []driver.Value
+	dispatched bool
+}
+
+var testDriver = &stubDriver{dbs: map[string]*stubTable{}}
+
+func init() {
+	sql.Register("outboxstub", testDriver)
+}
+
+func (d *stubDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &stubConn{table: d.dbs[name]}, nil
+}
+
+func openStubDB(t *testing.T) (*sql.DB, *stubTable) {
+	table := &stubTable{}
+	testDriver.mu.Lock()
+	testDriver.dbs[t.Name()] = table
+	testDriver.mu.Unlock()
+
+	db, err := sql.Open("outboxstub", t.Name())
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, table
+}
+
+type stubConn struct {
+	table   *stubTable
+	pending [][]driver.Value
+	inTx    bool
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{conn: c, query: query}, nil
+}
+
+func (c *stubConn) Close() error {
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *stubConn) Commit() error {
+	c.table.mu.Lock()
+	defer c.table.mu.Unlock()
+	for _, values := range c.pending {
+		c.table.rows = append(c.table.rows, stubRow{position: int64(len(c.table.rows) + 1), values: values})
+	}
+	c.pending, c.inTx = nil, false
+	return nil
+}
+
+func (c *stubConn) Rollback() error {
+	c.pending, c.inTx = nil, false
+	return nil
+}
+
+type stubStmt struct {
+	conn  *stubConn
+	query string
+}
+
+func (s *stubStmt) Close() error {
+	return nil
+}
+
+func (s *stubStmt) NumInput() int {
+	return -1
+}
+
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	switch {
+	case strings.HasPrefix(s.query, "INSERT INTO outbox "):
+		if !s.conn.inTx {
+			return nil, errors.New("insert outside transaction")
+		}
+		s.conn.pending = append(s.conn.pending, args)
+	case strings.HasPrefix(s.query, "UPDATE outbox SET dispatched_at"):
+		table := s.conn.table
+		table.mu.Lock()
+		defer table.mu.Unlock()
+		table.rows[args[1].(int64)-1].dispatched = true
+	default:
+		return nil, fmt.Errorf("unexpected query %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	var limit int
+	if _, err := fmt.Sscanf(s.query[strings.LastIndex(s.query, "LIMIT"):], "LIMIT %d", &limit); err != nil {
+		return nil, err
+	}
+
+	table := s.conn.table
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	rows := &stubRows{}
+	for _, row := range table.rows {
+		if !row.dispatched && len(rows.values) < limit {
+			rows.values = append(rows.values, append([]driver.Value{row.position}, row.values...))
+		}
+	}
+	return rows, nil
+}
+
+type stubRows struct {
+	values [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string {
+	return []string{"position", "id", "event_name", "envelope", "payload"}
+}
+
+func (r *stubRows) Close() error {
+	return nil
+}
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+type orderPlaced struct {
+	OrderID int `json:"order_id"`
+}
+
+func (orderPlaced) EventName() eventbus.EventName {
+	return "order.placed"
+}
+
+func writeEvents(t *testing.T, db *sql.DB, o *Outbox, commit bool, events ...any) {
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, o.Write(context.Background(), tx, events...))
+	if commit {
+		assert.NoError(t, tx.Commit())
+	} else {
+		assert.NoError(t, tx.Rollback())
+	}
+}
+
+func TestRelayPublishesCommittedEvents(t *testing.T) {
+	db, table := openStubDB(t)
+	o := New(eventbus.NewJSONCodec(orderPlaced{}), WithPlaceholder(Question), WithSource("orders"))
+
+	var published []*eventbus.Envelope
+	bus := eventbus.New()
+	bus.Subscribe(eventbus.EnvelopeHandlerFunc(func(ctx context.Context, envelope *eventbus.Envelope) error {
+		published = append(published, envelope)
+		return nil
+	}), "order.placed")
+
+	writeEvents(t, db, o, true, orderPlaced{OrderID: 1}, orderPlaced{OrderID: 2})
+	writeEvents(t, db, o, false, orderPlaced{OrderID: 3})
+
+	relay := NewRelay(db, o, bus)
+	n, err := relay.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	if assert.Len(t, published, 2) {
+		assert.Equal(t, orderPlaced{OrderID: 1}, published[0].Event)
+		assert.Equal(t, orderPlaced{OrderID: 2}, published[1].Event)
+		assert.Equal(t, "orders", published[0].Source)
+		assert.Equal(t, table.rows[0].values[0], published[0].ID)
+	}
+
+	//all the events are marked as dispatched
+	n, err = relay.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestRelayRedeliversFailedEvents(t *testing.T) {
+	db, _ := openStubDB(t)
+	o := New(eventbus.NewJSONCodec(orderPlaced{}))
+
+	fail := true
+	var ids []string
+	bus := eventbus.New()
+	bus.Subscribe(eventbus.EnvelopeHandlerFunc(func(ctx context.Context, envelope *eventbus.Envelope) error {
+		if envelope.Event.(orderPlaced).OrderID == 2 && fail {
+			return errors.New("unavailable")
+		}
+		ids = append(ids, envelope.ID)
+		return nil
+	}), "order.placed")
+
+	writeEvents(t, db, o, true, orderPlaced{OrderID: 1}, orderPlaced{OrderID: 2}, orderPlaced{OrderID: 3})
+
+	relay := NewRelay(db, o, bus)
+	n, err := relay.Dispatch(context.Background())
+	assert.ErrorContains(t, err, "unavailable")
+	assert.Equal(t, 1, n)
+	assert.Len(t, ids, 1)
+
+	fail = false
+	n, err = relay.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, ids, 3)
+}
+
+func TestRelayDeadLettersPoisonEvents(t *testing.T) {
+	db, _ := openStubDB(t)
+	o := New(eventbus.NewJSONCodec(orderPlaced{}))
+
+	var orders []int
+	bus := eventbus.New()
+	bus.Subscribe(eventbus.EventHandlerFunc(func(event any) error {
+		if event.(orderPlaced).OrderID == 1 {
+			return errors.New("poison")
+		}
+		orders = append(orders, event.(orderPlaced).OrderID)
+		return nil
+	}), "order.placed")
+
+	writeEvents(t, db, o, true, orderPlaced{OrderID: 1}, orderPlaced{OrderID: 2})
+
+	queue := eventbus.NewMemoryDeadLetterQueue()
+	relay := NewRelay(db, o, bus, WithMaxAttempts(2), WithDeadLetterQueue(queue))
+	n, err := relay.Dispatch(context.Background())
+	assert.ErrorContains(t, err, "poison")
+	assert.Equal(t, 0, n)
+	assert.Empty(t, orders)
+
+	//the last attempt dead letters the event, the events after it are published
+	n, err = relay.Dispatch(context.Background())
+	assert.ErrorContains(t, err, "poison")
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int{2}, orders)
+
+	letters, err := queue.List(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, letters, 1) {
+		assert.Equal(t, "order.placed", letters[0].EventName)
+		assert.Equal(t, 2, letters[0].Attempts)
+		assert.JSONEq(t, `{"order_id":1}`, string(letters[0].Event.(json.RawMessage)))
+	}
+
+	n, err = relay.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestRelayRun(t *testing.T) {
+	db, _ := openStubDB(t)
+	o := New(eventbus.NewJSONCodec(orderPlaced{}))
+
+	published := make(chan any, 10)
+	bus := eventbus.New()
+	bus.Subscribe(eventbus.EventHandlerFunc(func(event any) error {
+		published <- event
+		return nil
+	}), "order.placed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- NewRelay(db, o, bus, WithInterval(time.Millisecond), WithBatchSize(1)).Run(ctx)
+	}()
+
+	writeEvents(t, db, o, true, orderPlaced{OrderID: 1}, orderPlaced{OrderID: 2})
+	for i := 1; i <= 2; i++ {
+		select {
+		case event := <-published:
+			assert.Equal(t, orderPlaced{OrderID: i}, event)
+		case <-time.After(time.Second):
+			t.Fatal("event not relayed")
+		}
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+type otherPlaced struct{}
+
+func (otherPlaced) EventName() eventbus.EventName {
+	return "order.placed"
+}
+
+type legacyPlaced struct{}
+
+func TestOutboxNameRegistry(t *testing.T) {
+	db, table := openStubDB(t)
+	registry := eventbus.NewNameRegistry(nil)
+	assert.NoError(t, registry.Register(orderPlaced{}, "order.placed.v2"))
+	assert.NoError(t, registry.Register(legacyPlaced{}, "order.placed"))
+	o := New(eventbus.NewJSONCodecWithRegistry(registry, orderPlaced{}), WithNameRegistry(registry))
+
+	writeEvents(t, db, o, true, orderPlaced{OrderID: 1})
+	if assert.Len(t, table.rows, 1) {
+		assert.Equal(t, "order.placed.v2", table.rows[0].values[1])
+	}
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	defer tx.Rollback()
+	assert.ErrorIs(t, o.Write(context.Background(), tx, otherPlaced{}), eventbus.ErrEventNameCollision)
+}
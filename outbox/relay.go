@@ -0,0 +1,239 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mbict/go-eventbus/v2"
+)
+
+type RelayOption func(*Relay)
+
+// WithInterval sets the interval the relay polls the outbox table, the default is one second
+func WithInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.interval = interval
+	}
+}
+
+// WithBatchSize sets the maximum number of events read from the outbox table at once, the default is 100
+func WithBatchSize(size int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+// WithErrorHandler sets the handler for the errors of the polls, by default the errors are ignored and retried on the next poll
+func WithErrorHandler(handler func(error)) RelayOption {
+	return func(r *Relay) {
+		r.errorHandler = handler
+	}
+}
+
+// WithMaxAttempts sets the number of times the relay tries to publish an event, the default is 5. An event that
+// still fails is moved to the dead letter queue and marked as dispatched, so the events after it are published.
+// Zero keeps retrying the event, which blocks the events after it.
+func WithMaxAttempts(attempts int) RelayOption {
+	return func(r *Relay) {
+		r.maxAttempts = attempts
+	}
+}
+
+// WithDeadLetterQueue stores the events that failed all the attempts in the queue, without a queue they are
+// skipped and reported to the error handler
+func WithDeadLetterQueue(queue eventbus.DeadLetterQueue) RelayOption {
+	return func(r *Relay) {
+		r.deadLetterQueue = queue
+	}
+}
+
+// Relay publishes the events of the outbox table on the bus, in the order they were written.
+// An event is marked as dispatched after it is published, an event is published again when the relay stops in between.
+// Run a single relay per outbox table, concurrent relays publish the same events.
+type Relay struct {
+	db           *sql.DB
+	outbox       *Outbox
+	bus          eventbus.EventBus
+	interval     time.Duration
+	batchSize    int
+	errorHandler func(error)
+	maxAttempts  int
+	// attempts holds the failed attempts of the events by position
+	attempts        map[int64]int
+	deadLetterQueue eventbus.DeadLetterQueue
+	mu              sync.Mutex
+}
+
+func NewRelay(db *sql.DB, outbox *Outbox, bus eventbus.EventBus, options ...RelayOption) *Relay {
+	r := &Relay{
+		db:          db,
+		outbox:      outbox,
+		bus:         bus,
+		interval:    time.Second,
+		batchSize:   100,
+		maxAttempts: 5,
+		attempts:    make(map[int64]int),
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+// Run polls the outbox table until the context is cancelled
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll dispatches the batches until the outbox is empty
+func (r *Relay) poll(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.Dispatch(ctx)
+		if err != nil {
+			if r.errorHandler != nil && ctx.Err() == nil {
+				r.errorHandler(err)
+			}
+			return
+		}
+		if n < r.batchSize {
+			return
+		}
+	}
+}
+
+type record struct {
+	position  int64
+	id        string
+	eventName string
+	envelope  []byte
+	payload   []byte
+}
+
+// Dispatch publishes a batch of undispatched events and returns the number of dispatched events.
+// The batch stops at the first event that fails to publish, to keep the order of the events. An event that failed
+// the maximum number of attempts is dead lettered instead, its error is returned after the rest of the batch.
+func (r *Relay) Dispatch(ctx context.Context) (int, error) {
+	records, err := r.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	o := r.outbox
+	query := fmt.Sprintf("UPDATE %s SET dispatched_at = %s WHERE position = %s", o.table, o.placeholder(1), o.placeholder(2))
+	var deadLettered []error
+	for i, rec := range records {
+		if err := r.publish(ctx, rec); err != nil {
+			err = fmt.Errorf("outbox event %s: %w", rec.id, err)
+			if ctx.Err() != nil || !r.exhausted(rec) {
+				return i, errors.Join(append(deadLettered, err)...)
+			}
+			if err := r.deadLetter(ctx, rec, err); err != nil {
+				return i, errors.Join(append(deadLettered, err)...)
+			}
+			deadLettered = append(deadLettered, err)
+		}
+
+		if _, err := r.db.ExecContext(ctx, query, time.Now(), rec.position); err != nil {
+			return i, errors.Join(append(deadLettered, err)...)
+		}
+		r.mu.Lock()
+		delete(r.attempts, rec.position)
+		r.mu.Unlock()
+	}
+	return len(records), errors.Join(deadLettered...)
+}
+
+func (r *Relay) publish(ctx context.Context, rec record) error {
+	event, err := r.outbox.codec.Unmarshal(rec.eventName, rec.payload)
+	if err != nil {
+		return err
+	}
+
+	envelope := &eventbus.Envelope{}
+	if err := json.Unmarshal(rec.envelope, envelope); err != nil {
+		return err
+	}
+	envelope.Event = event
+
+	return r.bus.PublishContext(ctx, envelope)
+}
+
+// exhausted counts the failed attempt of the event and reports if it was the last attempt
+func (r *Relay) exhausted(rec record) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[rec.position]++
+	return r.maxAttempts > 0 && r.attempts[rec.position] >= r.maxAttempts
+}
+
+// deadLetter stores the event in the dead letter queue, the payload is stored as is
+func (r *Relay) deadLetter(ctx context.Context, rec record, err error) error {
+	if r.deadLetterQueue == nil {
+		return nil
+	}
+
+	envelope := &eventbus.Envelope{}
+	if json.Unmarshal(rec.envelope, envelope) != nil {
+		envelope = nil
+	}
+
+	var chain []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, e.Error())
+	}
+
+	r.mu.Lock()
+	attempts := r.attempts[rec.position]
+	r.mu.Unlock()
+
+	return r.deadLetterQueue.Add(ctx, eventbus.DeadLetter{
+		ID:        rec.id,
+		EventName: rec.eventName,
+		Event:     json.RawMessage(rec.payload),
+		Envelope:  envelope,
+		Handler:   "outbox.Relay",
+		Errors:    chain,
+		Attempts:  attempts,
+		FailedAt:  time.Now(),
+	})
+}
+
+func (r *Relay) load(ctx context.Context) ([]record, error) {
+	o := r.outbox
+	query := fmt.Sprintf("SELECT position, id, event_name, envelope, payload FROM %s WHERE dispatched_at IS NULL ORDER BY position LIMIT %d",
+		o.table, r.batchSize)
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []record
+	for rows.Next() {
+		var rec record
+		if err := rows.Scan(&rec.position, &rec.id, &rec.eventName, &rec.envelope, &rec.payload); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,105 @@
+package eventbus
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// PartitionKeyFunc maps an event to its partition key, for example the id of the aggregate the event belongs to
+type PartitionKeyFunc func(event any) string
+
+type partitionedDispatcher struct {
+	partitions []chan asyncJob
+	key        PartitionKeyFunc
+	run        func(asyncJob)
+	done       chan struct{}
+	once       sync.Once
+	// mu is held by the dispatching publishers, stop waits for them before it abandons the queued jobs
+	mu sync.RWMutex
+}
+
+func newPartitionedDispatcher(partitions int, queueSize int, key PartitionKeyFunc, run func(asyncJob)) *partitionedDispatcher {
+	d := &partitionedDispatcher{
+		partitions: make([]chan asyncJob, partitions),
+		key:        key,
+		run:        run,
+		done:       make(chan struct{}),
+	}
+	for i := range d.partitions {
+		d.partitions[i] = make(chan asyncJob, queueSize)
+		go d.work(d.partitions[i])
+	}
+	return d
+}
+
+// work handles the jobs of a single partition one by one
+func (d *partitionedDispatcher) work(jobs chan asyncJob) {
+	for {
+		select {
+		case job := <-jobs:
+			d.run(job)
+		case <-d.done:
+			return
+		}
+	}
+}
+
+func (d *partitionedDispatcher) partition(event any) chan asyncJob {
+	h := fnv.New32a()
+	h.Write([]byte(d.key(event)))
+	return d.partitions[h.Sum32()%uint32(len(d.partitions))]
+}
+
+func (d *partitionedDispatcher) dispatch(job asyncJob) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	//a job is never queued after the dispatcher is stopped
+	select {
+	case <-d.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case d.partition(job.event) <- job:
+		return nil
+	case <-job.ctx.Done():
+		return job.ctx.Err()
+	case <-d.done:
+		return ErrClosed
+	}
+}
+
+func (d *partitionedDispatcher) stop() {
+	d.once.Do(func() {
+		close(d.done)
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for _, jobs := range d.partitions {
+			abandonJobs(jobs)
+		}
+	})
+}
+
+// NewAsyncPartitioned creates an async bus that delivers the events with the same partition key to every handler
+// in publish order, events with different keys are handled in parallel over the partitions. A retried event holds
+// its partition during the backoff, so the order is kept.
+// When the queue of a partition is full the publisher blocks. Close the bus to stop the partitions.
+func NewAsyncPartitioned(partitions int, queueSize int, key PartitionKeyFunc, options ...Option) AsyncEventBus {
+	if partitions < 1 {
+		partitions = 1
+	}
+
+	eb := newAsyncEventBus()
+	dispatcher := newPartitionedDispatcher(partitions, queueSize, key, eb.handle)
+	eb.dispatcher = dispatcher
+	//a retry holds the partition, later events with the same key would overtake it otherwise
+	eb.retryHold = dispatcher.done
+
+	for _, option := range options {
+		option(eb)
+	}
+
+	return eb
+}
@@ -0,0 +1,119 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type orderEvent struct {
+	OrderID  int
+	Sequence int
+}
+
+func (orderEvent) EventName() EventName {
+	return "order.event"
+}
+
+func TestAsyncPartitionedDeliversInOrderPerKey(t *testing.T) {
+	var mu sync.Mutex
+	received := map[int][]int{}
+
+	bus := NewAsyncPartitioned(4, 10, func(event any) string {
+		return fmt.Sprint(event.(orderEvent).OrderID)
+	})
+	for i := 0; i < 2; i++ {
+		bus.Subscribe(EventHandlerFunc(func(event any) error {
+			e := event.(orderEvent)
+			//handling time varies per event, which would reorder events without partitioning
+			time.Sleep(time.Duration(e.Sequence%3) * time.Millisecond)
+			mu.Lock()
+			received[e.OrderID] = append(received[e.OrderID], e.Sequence)
+			mu.Unlock()
+			return nil
+		}), "order.event")
+	}
+
+	for seq := 0; seq < 10; seq++ {
+		for order := 0; order < 5; order++ {
+			assert.NoError(t, bus.Publish(orderEvent{OrderID: order, Sequence: seq}))
+		}
+	}
+
+	_, err := bus.Close(context.Background())
+	assert.NoError(t, err)
+
+	for order := 0; order < 5; order++ {
+		var expected []int
+		for seq := 0; seq < 10; seq++ {
+			//every event is received by both handlers
+			expected = append(expected, seq, seq)
+		}
+		assert.Equal(t, expected, received[order], "order %d", order)
+	}
+}
+
+func TestAsyncPartitionedHandlesKeysInParallel(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan int, 2)
+
+	bus := NewAsyncPartitioned(2, 10, func(event any) string {
+		//keys chosen to land on different partitions
+		return map[int]string{1: "a", 2: "b"}[event.(int)]
+	})
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		if event.(int) == 1 {
+			<-release
+		}
+		handled <- event.(int)
+		return nil
+	}))
+
+	assert.NoError(t, bus.Publish(1))
+	assert.NoError(t, bus.Publish(2))
+
+	select {
+	case n := <-handled:
+		assert.Equal(t, 2, n)
+	case <-time.After(time.Second):
+		t.Fatal("expected the event of the other partition to be handled")
+	}
+	close(release)
+	assert.NoError(t, bus.Drain(context.Background()))
+}
+
+func TestAsyncPartitionedKeepsOrderDuringRetry(t *testing.T) {
+	var mu sync.Mutex
+	var received []int
+	var attempts int
+
+	bus := NewAsyncPartitioned(1, 10, func(event any) string {
+		return fmt.Sprint(event.(orderEvent).OrderID)
+	})
+	bus.Subscribe(Retry(EventHandlerFunc(func(event any) error {
+		e := event.(orderEvent)
+		mu.Lock()
+		defer mu.Unlock()
+		//the first event fails once
+		if e.Sequence == 1 && attempts == 0 {
+			attempts++
+			return errTemporary
+		}
+		received = append(received, e.Sequence)
+		return nil
+	}), RetryPolicy{
+		MaxAttempts: 2,
+		Backoff:     ConstantBackoff(20 * time.Millisecond),
+	}), "order.event")
+
+	assert.NoError(t, bus.Publish(orderEvent{OrderID: 1, Sequence: 1}))
+	assert.NoError(t, bus.Publish(orderEvent{OrderID: 1, Sequence: 2}))
+
+	_, err := bus.Close(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, received)
+}
@@ -0,0 +1,83 @@
+package eventbus
+
+import (
+	"errors"
+	"sync"
+)
+
+// PublishResult is the future of an asynchronously published event, it completes when all the handlers are done.
+type PublishResult struct {
+	mu      sync.Mutex
+	pending int
+	begun   bool
+	errs    []error
+	done    chan struct{}
+	release func()
+}
+
+func newPublishResult() *PublishResult {
+	return &PublishResult{
+		done: make(chan struct{}),
+	}
+}
+
+// add registers the number of handler calls to wait for, it must be called before the handlers are started
+func (r *PublishResult) add(n int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.pending += n
+	r.begun = true
+	r.mu.Unlock()
+}
+
+// started reports if handler calls are registered
+func (r *PublishResult) started() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.begun
+}
+
+// finish registers the outcome of a single handler call
+func (r *PublishResult) finish(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
+	r.pending--
+	if r.pending == 0 {
+		close(r.done)
+		if r.release != nil {
+			r.release()
+		}
+	}
+}
+
+// complete marks the result as done when no handler calls are pending
+func (r *PublishResult) complete(err error) {
+	r.add(1)
+	r.finish(err)
+}
+
+// Done returns a channel that is closed when all the handlers are done
+func (r *PublishResult) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait blocks until all the handlers are done and returns the joined errors of all the handlers
+func (r *PublishResult) Wait() error {
+	<-r.done
+	return r.Err()
+}
+
+// Err returns the joined errors of the handlers that are done
+func (r *PublishResult) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Join(r.errs...)
+}
@@ -0,0 +1,78 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// HandlerPanicError is the error a panic in a handler is converted to. A panic while publishing on a channeled bus,
+// outside the handlers, is converted to a HandlerPanicError without an event name.
+type HandlerPanicError struct {
+	EventName EventName
+	Event     any
+	Value     any
+	Stack     []byte
+}
+
+func (e *HandlerPanicError) Error() string {
+	if e.EventName == "" {
+		return fmt.Sprintf("panic while publishing %T: %v", e.Event, e.Value)
+	}
+	return fmt.Sprintf("panic in handler for event %s: %v", e.EventName, e.Value)
+}
+
+// Retryable reports a panic as not retryable for the retry policies
+func (e *HandlerPanicError) Retryable() bool {
+	return false
+}
+
+// Unwrap returns the panic value when it is an error
+func (e *HandlerPanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+type repanicSetter interface {
+	setRepanic(bool)
+}
+
+// errorReporter is implemented by the buses that pass an error outside a publish to their error handler
+type errorReporter interface {
+	reportError(err error, event any) error
+}
+
+// reportError passes the error to the error handler of the bus, wrapping buses delegate to the bus they wrap.
+// The error is returned as is by a bus without an error handler.
+func reportError(bus EventBus, err error, event any) error {
+	if reporter, ok := bus.(errorReporter); ok {
+		return reporter.reportError(err, event)
+	}
+	return err
+}
+
+// recoverHandler calls the handler and converts a panic in a HandlerPanicError
+func recoverHandler(ctx context.Context, handler EventHandler, name EventName, event any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &HandlerPanicError{
+				EventName: name,
+				Event:     event,
+				Value:     r,
+				Stack:     debug.Stack(),
+			}
+		}
+	}()
+	return handleEvent(ctx, handler, event)
+}
+
+// repanicWhenRecovered panics again with the error when it is the result of a recovered panic
+func repanicWhenRecovered(err error) {
+	var p *HandlerPanicError
+	if errors.As(err, &p) {
+		panic(p)
+	}
+}
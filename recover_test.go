@@ -0,0 +1,140 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBusRecoversHandlerPanic(t *testing.T) {
+	bus := New()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		panic("boom")
+	}), EventA)
+
+	err := bus.Publish(&TestEventA{})
+
+	var panicErr *HandlerPanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, EventA, panicErr.EventName)
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+}
+
+func TestEventBusRoutesHandlerPanicToErrorHandler(t *testing.T) {
+	var handledErr error
+	bus := New(WithErrorHandler(func(err error, event any) error {
+		handledErr = err
+		return nil
+	}))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		panic(context.Canceled)
+	}), EventA)
+
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+	assert.ErrorIs(t, handledErr, context.Canceled)
+}
+
+func TestEventBusRepanic(t *testing.T) {
+	errorHandlerCalled := false
+	bus := New(WithRepanic(), WithErrorHandler(func(err error, event any) error {
+		errorHandlerCalled = true
+		return nil
+	}))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		panic("boom")
+	}), EventA)
+
+	assert.PanicsWithError(t, "panic in handler for event event:test1: boom", func() {
+		_ = bus.Publish(&TestEventA{})
+	})
+	assert.True(t, errorHandlerCalled)
+}
+
+func TestAsyncEventBusRecoversHandlerPanic(t *testing.T) {
+	errs := make(chan error, 1)
+	bus := NewAsync(WithErrorHandler(func(err error, event any) error {
+		errs <- err
+		return err
+	}))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		panic("boom")
+	}))
+
+	err := bus.PublishAsync(context.Background(), &TestEventA{}).Wait()
+
+	var panicErr *HandlerPanicError
+	assert.True(t, errors.As(err, &panicErr))
+	select {
+	case err := <-errs:
+		assert.Equal(t, panicErr, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected the error handler to be called")
+	}
+}
+
+func TestChanneledEventBusSurvivesHandlerPanic(t *testing.T) {
+	handled := make(chan any, 1)
+	bus, cancelFunc := NewChanneldWith(New())
+	defer cancelFunc()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		if event == 1 {
+			panic("boom")
+		}
+		handled <- event
+		return nil
+	}))
+
+	assert.NoError(t, bus.Publish(1))
+	assert.NoError(t, bus.Publish(2))
+
+	select {
+	case event := <-handled:
+		assert.Equal(t, 2, event)
+	case <-time.After(time.Second):
+		t.Fatal("expected the loop to handle the next event")
+	}
+}
+
+func TestChanneledEventBusSurvivesPublishMiddlewarePanic(t *testing.T) {
+	handled := make(chan any, 1)
+	errs := make(chan error, 1)
+	bus, cancelFunc := NewChanneldWith(New(
+		WithErrorHandler(func(err error, event any) error {
+			errs <- err
+			return nil
+		}),
+		WithPublishMiddleware(func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, event any) error {
+				if event == 1 {
+					panic("boom")
+				}
+				return next(ctx, event)
+			}
+		}),
+	))
+	defer cancelFunc()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		handled <- event
+		return nil
+	}))
+
+	assert.NoError(t, bus.Publish(1))
+	assert.NoError(t, bus.Publish(2))
+
+	select {
+	case event := <-handled:
+		assert.Equal(t, 2, event)
+	case <-time.After(time.Second):
+		t.Fatal("expected the loop to handle the next event")
+	}
+
+	var panicErr *HandlerPanicError
+	if assert.ErrorAs(t, <-errs, &panicErr) {
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.Equal(t, 1, panicErr.Event)
+	}
+}
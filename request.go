@@ -0,0 +1,140 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrNoReply is returned when all the handlers of a request are done without replying
+	ErrNoReply = errors.New("no reply")
+
+	// ErrNotRequest is returned when replying to an event that is not published as a request
+	ErrNotRequest = errors.New("event is not a request")
+
+	// ErrRequestCompleted is returned when replying to a request that already got its reply or timed out
+	ErrRequestCompleted = errors.New("request completed")
+)
+
+// replySink collects the replies of a request
+type replySink struct {
+	mu      sync.Mutex
+	first   bool
+	replies []any
+	closed  bool
+	replied chan struct{}
+}
+
+func (s *replySink) add(reply any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || (s.first && len(s.replies) > 0) {
+		return ErrRequestCompleted
+	}
+
+	s.replies = append(s.replies, reply)
+	if s.first {
+		close(s.replied)
+	}
+	return nil
+}
+
+// close stops accepting replies and returns the received replies
+func (s *replySink) close() []any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.replies
+}
+
+// Reply sends the reply to the requester of the event, see Request
+func (e *Envelope) Reply(reply any) error {
+	if e.reply == nil {
+		return ErrNotRequest
+	}
+	return e.reply.add(reply)
+}
+
+// Reply sends the reply to the requester of the event that is handled, a handler of a request
+// replies with the context it received
+func Reply(ctx context.Context, reply any) error {
+	envelope, ok := EnvelopeFromContext(ctx)
+	if !ok {
+		return ErrNotRequest
+	}
+	return envelope.Reply(reply)
+}
+
+// Request publishes the event as a request and returns the first reply of the handlers, the other replies are dropped.
+// ErrNoReply is returned when all the handlers are done without replying, or the error of the handlers when they fail.
+// Use a context with a timeout to limit the wait for the reply, the context error is returned on a timeout.
+//
+// On an async bus the request waits for the handlers to complete. A channeled bus does not report when its handlers
+// are done, a request on a channeled bus without a reply waits until the context is done.
+func Request(ctx context.Context, bus EventBus, event any) (any, error) {
+	replies, err := request(ctx, bus, event, true)
+	if len(replies) > 0 {
+		return replies[0], nil
+	}
+	return nil, err
+}
+
+// RequestAll publishes the event as a request and gathers the replies of all the handlers, in the order they replied.
+// When the context is done before the handlers the gathered replies are returned with the context error.
+func RequestAll(ctx context.Context, bus EventBus, event any) ([]any, error) {
+	return request(ctx, bus, event, false)
+}
+
+func request(ctx context.Context, bus EventBus, event any, first bool) ([]any, error) {
+	sink := &replySink{first: first}
+	if first {
+		sink.replied = make(chan struct{})
+	}
+
+	envelope := newEnvelope(ctx, event, "")
+	envelope.reply = sink
+	done, publishErr := publishRequest(ctx, bus, envelope)
+
+	select {
+	case <-sink.replied:
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	replies := sink.close()
+	if first && len(replies) > 0 {
+		return replies, nil
+	}
+
+	select {
+	case <-done:
+		if err := publishErr(); err != nil {
+			return replies, err
+		}
+		if len(replies) == 0 {
+			return nil, ErrNoReply
+		}
+		return replies, nil
+	default:
+		return replies, ctx.Err()
+	}
+}
+
+// publishRequest publishes the request and returns a channel that is closed when all the handlers are done,
+// the channel is nil when the bus does not report it
+func publishRequest(ctx context.Context, bus EventBus, envelope *Envelope) (<-chan struct{}, func() error) {
+	if async, ok := bus.(AsyncEventBus); ok {
+		result := async.PublishAsync(ctx, envelope)
+		return result.Done(), result.Err
+	}
+
+	err := bus.PublishContext(ctx, envelope)
+	if _, ok := bus.(*channeledEventBus); ok && err == nil {
+		return nil, func() error { return nil }
+	}
+
+	done := make(chan struct{})
+	close(done)
+	return done, func() error { return err }
+}
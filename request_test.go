@@ -0,0 +1,122 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func replyingHandler(reply any) EventHandler {
+	return ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		return Reply(ctx, reply)
+	})
+}
+
+func TestRequest(t *testing.T) {
+	bus := New()
+	bus.Subscribe(replyingHandler("first"), "query")
+	bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		assert.ErrorIs(t, Reply(ctx, "second"), ErrRequestCompleted)
+		return nil
+	}), "query")
+
+	reply, err := Request(context.Background(), bus, namedEvent("query"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first", reply)
+}
+
+func TestRequestNoReply(t *testing.T) {
+	bus := New(WithErrorHandler(func(err error, event any) error {
+		return err
+	}))
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		return nil
+	}), "query")
+
+	_, err := Request(context.Background(), bus, namedEvent("query"))
+	assert.ErrorIs(t, err, ErrNoReply)
+
+	_, err = Request(context.Background(), bus, namedEvent("unknown"))
+	assert.ErrorIs(t, err, ErrNoReply)
+
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		return errors.New("failed")
+	}), "query")
+	_, err = Request(context.Background(), bus, namedEvent("query"))
+	assert.EqualError(t, err, "failed")
+}
+
+func TestRequestAll(t *testing.T) {
+	bus := New()
+	bus.Subscribe(replyingHandler("a"), "query")
+	bus.Subscribe(replyingHandler("b"), "query")
+
+	replies, err := RequestAll(context.Background(), bus, namedEvent("query"))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"a", "b"}, replies)
+}
+
+func TestRequestAsync(t *testing.T) {
+	bus := NewAsync()
+	bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		time.Sleep(10 * time.Millisecond)
+		return Reply(ctx, "slow")
+	}), "query")
+	bus.Subscribe(replyingHandler("fast"), "query")
+
+	reply, err := Request(context.Background(), bus, namedEvent("query"))
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", reply)
+
+	replies, err := RequestAll(context.Background(), bus, namedEvent("query"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []any{"fast", "slow"}, replies)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	bus, cancel := NewChanneldWith(New())
+	defer cancel()
+
+	release := make(chan struct{})
+	bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		<-release
+		return nil
+	}), "query")
+	bus.Subscribe(replyingHandler("channeled"), "other")
+
+	ctx, cancelRequest := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelRequest()
+	_, err := Request(ctx, bus, namedEvent("query"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	close(release)
+
+	reply, err := Request(context.Background(), bus, namedEvent("other"))
+	assert.NoError(t, err)
+	assert.Equal(t, "channeled", reply)
+}
+
+func TestReplyOutsideRequest(t *testing.T) {
+	bus := New()
+	var errs []error
+	bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		errs = append(errs, Reply(ctx, "reply"))
+		//events published by the handler are no requests
+		return bus.PublishContext(ctx, namedEvent("caused"))
+	}), "query")
+	bus.Subscribe(ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		errs = append(errs, Reply(ctx, "reply"))
+		return nil
+	}), "caused")
+
+	assert.NoError(t, bus.Publish(namedEvent("query")))
+	assert.Equal(t, []error{ErrNotRequest, ErrNotRequest}, errs)
+
+	errs = nil
+	_, err := Request(context.Background(), bus, namedEvent("query"))
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, ErrNotRequest}, errs)
+	assert.ErrorIs(t, Reply(context.Background(), "reply"), ErrNotRequest)
+}
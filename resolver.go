@@ -1,17 +1,238 @@
 package eventbus
 
-import "reflect"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrEventNameCollision is returned when two types claim the same event name
+var ErrEventNameCollision = errors.New("event name collision")
 
 type EventNameResolver func(event any) string
 
+// Resolve returns the name of the event, a resolver func never fails
+func (f EventNameResolver) Resolve(event any) (EventName, error) {
+	return f(event), nil
+}
+
+// nameResolver resolves the name of a published event, implemented by the EventNameResolver and the NameRegistry
+type nameResolver interface {
+	Resolve(event any) (EventName, error)
+}
+
+// eventNamer is implemented by the buses that can resolve the name of an event with their resolver
+type eventNamer interface {
+	eventName(event any) (EventName, error)
+}
+
+// busEventName resolves the name of the event as the bus does, wrapping buses delegate to the bus they wrap.
+// The events of the other buses are named by ResolveEventName.
+func busEventName(bus EventBus, event any) (EventName, error) {
+	if namer, ok := bus.(eventNamer); ok {
+		return namer.eventName(event)
+	}
+	return resolveEventName(event), nil
+}
+
+// defaultNameResolver names the events of a bus without a registry, collisions are not detected
+var defaultNameResolver = EventNameResolver(resolveEventName)
+
+// ResolveEventName returns the name of the event without a registry: the name returned by its Event implementation,
+// or the name derived by the TypeNameStrategy. A pointer and its value type have the same name.
+func ResolveEventName(event any) EventName {
+	return resolveEventName(event)
+}
+
 func resolveEventName(event any) string {
-	if c, ok := event.(Event); ok {
-		return c.EventName()
+	if e, ok := event.(Event); ok && !isNilPointer(event) {
+		return e.EventName()
 	}
+	t := baseType(reflect.TypeOf(event))
+	if t == nil {
+		return ""
+	}
+	return typeEventName(t, TypeNameStrategy)
+}
 
-	t := reflect.TypeOf(event)
-	if t.Kind() == reflect.Ptr {
-		return t.Elem().PkgPath() + "/*" + t.Elem().Name()
+// typeEventName returns the name of the zero value of the type, or the name by the strategy. The wildcard "*" is
+// returned for an interface.
+func typeEventName(t reflect.Type, strategy NameStrategy) EventName {
+	t = baseType(t)
+	switch {
+	case t == nil || t.Kind() == reflect.Interface:
+		return "*"
+	case t.Implements(eventImpl):
+		return reflect.Zero(t).Interface().(Event).EventName()
+	case reflect.PointerTo(t).Implements(eventImpl):
+		return reflect.New(t).Interface().(Event).EventName()
+	}
+	return strategy(t)
+}
+
+// NameStrategy derives the name of an event type that is not registered and does not implement the Event interface
+type NameStrategy func(t reflect.Type) EventName
+
+// TypeNameStrategy names the type after its package path and type name, like "github.com/acme/orders/OrderPlaced"
+func TypeNameStrategy(t reflect.Type) EventName {
+	if t.Name() == "" {
+		return t.String()
 	}
 	return t.PkgPath() + "/" + t.Name()
 }
+
+// ShortNameStrategy names the type after its package name and type name, like "orders.OrderPlaced"
+func ShortNameStrategy(t reflect.Type) EventName {
+	return t.String()
+}
+
+// NameRegistry maps the Go types to event names. The name of a type is the explicitly registered name, the name
+// returned by its Event implementation, or the name derived by the strategy. A pointer and its value type have the
+// same name. Two types claiming the same name are reported as an ErrEventNameCollision.
+//
+// A type claims its registered name, or the name of its zero value. An Event implementation that returns a different
+// name per instance, like a topic, is named by the instance without claiming the name, it only collides with a name
+// claimed by another type. The buses detect collisions when they are configured with a registry by WithNameRegistry.
+type NameRegistry struct {
+	mu       sync.RWMutex
+	strategy NameStrategy
+	// registered holds the explicitly registered names
+	registered map[reflect.Type]EventName
+	// derived caches the names derived from the types
+	derived map[reflect.Type]EventName
+	// claims holds the type that claimed the name
+	claims map[EventName]reflect.Type
+}
+
+// NewNameRegistry creates a registry, the strategy names the types that do not implement the Event interface.
+// The TypeNameStrategy is used without a strategy.
+func NewNameRegistry(strategy NameStrategy) *NameRegistry {
+	if strategy == nil {
+		strategy = TypeNameStrategy
+	}
+	return &NameRegistry{
+		strategy:   strategy,
+		registered: make(map[reflect.Type]EventName),
+		derived:    make(map[reflect.Type]EventName),
+		claims:     make(map[EventName]reflect.Type),
+	}
+}
+
+// Register registers the name for the type of the event, the name takes precedence over the Event implementation
+// of the type. An error is returned when the name is claimed by another type, or the type is registered under
+// another name.
+func (r *NameRegistry) Register(event any, name EventName) error {
+	t := baseType(reflect.TypeOf(event))
+	if t == nil {
+		return fmt.Errorf("unable to register the name %s for a nil event", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.registered[t]; ok && current != name {
+		return fmt.Errorf("%w: %s is already registered as %s", ErrEventNameCollision, t, current)
+	}
+	if other, ok := r.claims[name]; ok && other != t {
+		return fmt.Errorf("%w: %s is claimed by %s and %s", ErrEventNameCollision, name, other, t)
+	}
+
+	//the registered name replaces the name claimed by the type before
+	if derived, ok := r.derived[t]; ok && r.claims[derived] == t {
+		delete(r.claims, derived)
+	}
+	delete(r.derived, t)
+
+	r.registered[t] = name
+	r.claims[name] = t
+	return nil
+}
+
+// Name returns the name of the event, use Resolve to detect a collision
+func (r *NameRegistry) Name(event any) EventName {
+	name, _ := r.Resolve(event)
+	return name
+}
+
+// Resolve returns the name of the event, an error is returned when the name is claimed by another type
+func (r *NameRegistry) Resolve(event any) (EventName, error) {
+	t := baseType(reflect.TypeOf(event))
+	if t == nil {
+		return "", nil
+	}
+
+	//the type claims the name of its zero value
+	typeName, err := r.TypeName(t)
+	if err != nil {
+		return typeName, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := event.(Event)
+	if _, registered := r.registered[t]; registered || !ok || isNilPointer(event) {
+		return typeName, nil
+	}
+
+	//the instance name is not claimed, a type can return any number of names
+	name := e.EventName()
+	if other, claimed := r.claims[name]; claimed && other != t {
+		return name, fmt.Errorf("%w: %s is claimed by %s and %s", ErrEventNameCollision, name, other, t)
+	}
+	return name, nil
+}
+
+// TypeName returns the name of the type, a pointer type has the name of its value type. The wildcard "*" is returned
+// for an interface.
+func (r *NameRegistry) TypeName(t reflect.Type) (EventName, error) {
+	t = baseType(t)
+	if t == nil || t.Kind() == reflect.Interface {
+		return "*", nil
+	}
+
+	r.mu.RLock()
+	name, ok := r.registered[t]
+	if !ok {
+		name, ok = r.derived[t]
+	}
+	r.mu.RUnlock()
+	if ok {
+		return name, nil
+	}
+
+	name = typeEventName(t, r.strategy)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if other, ok := r.claims[name]; ok && other != t {
+		return name, fmt.Errorf("%w: %s is claimed by %s and %s", ErrEventNameCollision, name, other, t)
+	}
+	//an Event implementation without a name for its zero value names its instances
+	if name != "" {
+		r.claims[name] = t
+	}
+	r.derived[t] = name
+	return name, nil
+}
+
+// Type returns the type that claimed the name
+func (r *NameRegistry) Type(name EventName) (reflect.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.claims[name]
+	return t, ok
+}
+
+// baseType returns the value type of a pointer type
+func baseType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
@@ -0,0 +1,211 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type plainEvent struct{}
+
+type otherEventA struct{}
+
+func (otherEventA) EventName() EventName {
+	return "eventA"
+}
+
+func TestNameRegistryNormalisesPointers(t *testing.T) {
+	r := NewNameRegistry(nil)
+
+	assert.Equal(t, "github.com/mbict/go-eventbus/v2/plainEvent", r.Name(plainEvent{}))
+	assert.Equal(t, r.Name(plainEvent{}), r.Name(&plainEvent{}))
+
+	//the Event implementation with a pointer receiver names the value
+	assert.Equal(t, EventA, r.Name(TestEventA{}))
+	assert.Equal(t, EventA, r.Name(&TestEventA{}))
+	assert.Equal(t, EventA, r.Name((*TestEventA)(nil)))
+
+	name, err := r.TypeName(reflect.TypeOf(&TestEventA{}))
+	assert.NoError(t, err)
+	assert.Equal(t, EventA, name)
+}
+
+func TestNameRegistryStrategy(t *testing.T) {
+	r := NewNameRegistry(ShortNameStrategy)
+	assert.Equal(t, "eventbus.plainEvent", r.Name(&plainEvent{}))
+	assert.Equal(t, "eventA", r.Name(eventA("a")))
+}
+
+func TestNameRegistryRegister(t *testing.T) {
+	r := NewNameRegistry(nil)
+
+	assert.Equal(t, "eventA", r.Name(eventA("a")))
+	assert.NoError(t, r.Register(eventA(""), "renamed"))
+	assert.Equal(t, "renamed", r.Name(eventA("a")))
+
+	//the name is released by the type
+	assert.NoError(t, r.Register(plainEvent{}, "eventA"))
+	typ, ok := r.Type("eventA")
+	assert.True(t, ok)
+	assert.Equal(t, reflect.TypeOf(plainEvent{}), typ)
+
+	assert.ErrorIs(t, r.Register(&plainEvent{}, "other"), ErrEventNameCollision)
+	assert.ErrorIs(t, r.Register(eventB(""), "renamed"), ErrEventNameCollision)
+}
+
+func TestNameRegistryCollision(t *testing.T) {
+	r := NewNameRegistry(nil)
+
+	_, err := r.TypeName(reflect.TypeOf(eventA("")))
+	assert.NoError(t, err)
+
+	_, err = r.TypeName(reflect.TypeOf(otherEventA{}))
+	assert.ErrorIs(t, err, ErrEventNameCollision)
+
+	name, err := r.Resolve(otherEventA{})
+	assert.Equal(t, "eventA", name)
+	assert.EqualError(t, err, "event name collision: eventA is claimed by eventbus.eventA and eventbus.otherEventA")
+
+	//instance names are not claimed, they only collide with the names claimed by other types
+	assert.Equal(t, "order.created", r.Name(namedEvent("order.created")))
+	_, ok := r.Type("order.created")
+	assert.False(t, ok)
+	name, err = r.Resolve(namedEvent("eventA"))
+	assert.Equal(t, "eventA", name)
+	assert.ErrorIs(t, err, ErrEventNameCollision)
+}
+
+func TestNameRegistryDoesNotClaimInstanceNames(t *testing.T) {
+	r := NewNameRegistry(nil)
+	for i := 0; i < 100; i++ {
+		_, err := r.Resolve(namedEvent(fmt.Sprintf("topic.%d", i)))
+		assert.NoError(t, err)
+	}
+	assert.Empty(t, r.claims)
+}
+
+func TestDefaultBusesDoNotDetectCollisions(t *testing.T) {
+	assert.NoError(t, New().Publish(sameNameA{}))
+	assert.NoError(t, New().Publish(sameNameB{}))
+	assert.Equal(t, "zz.same", ResolveEventName(&sameNameB{}))
+}
+
+type sameNameA struct{}
+
+func (sameNameA) EventName() EventName { return "zz.same" }
+
+type sameNameB struct{}
+
+func (sameNameB) EventName() EventName { return "zz.same" }
+
+func TestNameRegistryResolveClaimsNames(t *testing.T) {
+	r := NewNameRegistry(nil)
+
+	name, err := r.Resolve(sameNameA{})
+	assert.NoError(t, err)
+	assert.Equal(t, "zz.same", name)
+
+	_, err = r.Resolve(&sameNameA{})
+	assert.NoError(t, err)
+
+	_, err = r.Resolve(sameNameB{})
+	assert.ErrorIs(t, err, ErrEventNameCollision)
+
+	bus := New(WithNameRegistry(r))
+	assert.NoError(t, bus.Publish(sameNameA{}))
+	assert.ErrorIs(t, bus.Publish(sameNameB{}), ErrEventNameCollision)
+}
+
+type placedEvent struct{}
+
+func (placedEvent) EventName() EventName { return "order.placed" }
+
+type generatedHandler struct {
+	called int
+}
+
+func (h *generatedHandler) EventbusHandlers() []GeneratedHandler {
+	return []GeneratedHandler{{
+		Event:  reflect.TypeOf(placedEvent{}),
+		Method: "HandlePlaced",
+		Handler: func(ctx context.Context, event any) ([]Event, error) {
+			h.called++
+			return nil, nil
+		},
+	}}
+}
+
+func TestGeneratedHandlersUseNameRegistry(t *testing.T) {
+	r := NewNameRegistry(nil)
+	assert.NoError(t, r.Register(placedEvent{}, "placed.v2"))
+
+	h := &generatedHandler{}
+	bus := New(WithNameRegistry(r))
+	_, err := SubscribeInstance(bus, h, WithResolverNameRegistry(r))
+	assert.NoError(t, err)
+
+	assert.NoError(t, bus.Publish(placedEvent{}))
+	assert.Equal(t, 1, h.called)
+
+	other := NewNameRegistry(nil)
+	assert.NoError(t, other.Register(plainEvent{}, "order.placed"))
+	_, err = EventHandlerResolver(h, WithResolverNameRegistry(other))
+	assert.ErrorIs(t, err, ErrEventNameCollision)
+}
+
+func TestNameRegistryIsUsedByTheBuses(t *testing.T) {
+	r := NewNameRegistry(nil)
+	_, err := r.TypeName(reflect.TypeOf(eventA("")))
+	assert.NoError(t, err)
+
+	for name, bus := range map[string]EventBus{
+		"sync":  New(WithNameRegistry(r)),
+		"async": NewAsync(WithNameRegistry(r)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var called int
+			bus.Subscribe(EventHandlerFunc(func(event any) error {
+				called++
+				return nil
+			}), "eventA")
+
+			assert.NoError(t, bus.Publish(eventA("a")))
+			assert.ErrorIs(t, bus.Publish(otherEventA{}), ErrEventNameCollision)
+			if async, ok := bus.(AsyncEventBus); ok {
+				_, err := async.Close(context.Background())
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, 1, called)
+		})
+	}
+}
+
+func TestAsyncBusUsesEventNameResolver(t *testing.T) {
+	bus := NewAsync(WithEventNameResolver(func(event any) string {
+		return "resolved"
+	}))
+
+	handled := make(chan any, 1)
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		handled <- event
+		return nil
+	}), "resolved")
+
+	assert.NoError(t, bus.PublishAsync(context.Background(), eventA("a")).Wait())
+	assert.Equal(t, eventA("a"), <-handled)
+}
+
+func TestEventHandlerResolverNameCollision(t *testing.T) {
+	r := NewNameRegistry(nil)
+	_, err := r.TypeName(reflect.TypeOf(otherEventA{}))
+	assert.NoError(t, err)
+
+	_, err = EventHandlerResolver(&testHandlerInstance{}, WithResolverNameRegistry(r))
+	assert.ErrorIs(t, err, ErrEventNameCollision)
+
+	_, err = SubscribeInstance(New(WithNameRegistry(r)), &validHandlerInstance{}, WithResolverNameRegistry(r))
+	assert.ErrorIs(t, err, ErrEventNameCollision)
+}
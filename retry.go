@@ -0,0 +1,200 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Backoff returns the delay before the next attempt, after the given number of failed attempts
+type Backoff func(attempt int) time.Duration
+
+// ConstantBackoff waits the same delay between all the attempts
+func ConstantBackoff(delay time.Duration) Backoff {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialBackoff doubles the delay after every failed attempt, starting with the initial delay up to the max delay
+func ExponentialBackoff(initial time.Duration, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		delay := initial
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			return max
+		}
+		return delay
+	}
+}
+
+// JitteredBackoff randomizes the delay of the backoff between half and the full delay,
+// so handlers failing at the same moment do not retry at the same moment
+func JitteredBackoff(backoff Backoff) Backoff {
+	return func(attempt int) time.Duration {
+		delay := backoff(attempt)
+		if delay <= 1 {
+			return delay
+		}
+		return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+	}
+}
+
+// RetryPolicy configures the retries of a handler
+type RetryPolicy struct {
+	// MaxAttempts is the number of times the handler is called, including the first call
+	MaxAttempts int
+	// Backoff determines the delay between the attempts, without a backoff the handler is retried immediately
+	Backoff Backoff
+	// Retryable classifies the errors that are retried, by default IsRetryable is used
+	Retryable func(error) bool
+}
+
+// next returns the delay before the next attempt, or false when the call should not be retried
+func (p *RetryPolicy) next(attempt int, err error) (time.Duration, bool) {
+	if p == nil || err == nil || attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	if !retryable(err) {
+		return 0, false
+	}
+
+	if p.Backoff == nil {
+		return 0, true
+	}
+	return p.Backoff(attempt), true
+}
+
+// result wraps the error of the last attempt in a RetryError
+func (p *RetryPolicy) result(attempt int, err error) error {
+	if p == nil || err == nil {
+		return err
+	}
+	return &RetryError{Attempts: attempt, Err: err}
+}
+
+// RetryError is returned when a handler with a retry policy fails permanently
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("handler failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+func (e permanentError) Retryable() bool {
+	return false
+}
+
+// Permanent marks the error as not retryable
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// IsRetryable is the default error classification, every error is retryable unless an error in the chain
+// implements a Retryable() bool method that returns false, like the errors marked with Permanent and handler panics.
+// A cancelled or expired context is never retried.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var classified interface{ Retryable() bool }
+	if errors.As(err, &classified) {
+		return classified.Retryable()
+	}
+	return true
+}
+
+// RetryOn returns a classification that only retries the errors matching one of the provided errors
+func RetryOn(errs ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range errs {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// retryHandler holds the retry policy of a handler, the bus uses the policy of the subscription to retry the calls
+type retryHandler struct {
+	handler EventHandler
+	policy  RetryPolicy
+}
+
+// Retry attaches the retry policy to the handler. When subscribed, the sync bus retries the failed calls
+// blocking the publisher, the async and channeled buses reschedule the calls without blocking.
+// The error of the last attempt is wrapped in a RetryError.
+func Retry(handler EventHandler, policy RetryPolicy) EventHandler {
+	return &retryHandler{handler: handler, policy: policy}
+}
+
+func (h *retryHandler) Handle(event any) error {
+	return h.HandleContext(context.Background(), event)
+}
+
+// HandleContext calls the handler and retries blocking, used when the handler is called outside a bus
+func (h *retryHandler) HandleContext(ctx context.Context, event any) error {
+	return retryBlocking(ctx, &h.policy, 1, func() error {
+		return handleEvent(ctx, h.handler, event)
+	})
+}
+
+// retryBlocking calls the func until it succeeds or the policy gives up, sleeping between the attempts
+func retryBlocking(ctx context.Context, policy *RetryPolicy, attempt int, call func() error) error {
+	for ; ; attempt++ {
+		err := call()
+		delay, retry := policy.next(attempt, err)
+		if !retry {
+			return policy.result(attempt, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return policy.result(attempt, err)
+		}
+	}
+}
+
+// retryScheduler is provided in the context by a bus that reschedules retries instead of blocking
+type retryScheduler interface {
+	scheduleRetry(delay time.Duration, retry func())
+}
+
+type retrySchedulerKey struct{}
+
+func withRetryScheduler(ctx context.Context, scheduler retryScheduler) context.Context {
+	return context.WithValue(ctx, retrySchedulerKey{}, scheduler)
+}
+
+func retrySchedulerFromContext(ctx context.Context) (retryScheduler, bool) {
+	scheduler, ok := ctx.Value(retrySchedulerKey{}).(retryScheduler)
+	return scheduler, ok
+}
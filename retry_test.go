@@ -0,0 +1,202 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTemporary = errors.New("temporary error")
+
+// failingHandler fails the first calls with the error
+func failingHandler(failures int32, err error, calls *atomic.Int32) EventHandler {
+	return EventHandlerFunc(func(event any) error {
+		if calls.Add(1) <= failures {
+			return err
+		}
+		return nil
+	})
+}
+
+func TestBackoffs(t *testing.T) {
+	constant := ConstantBackoff(time.Second)
+	assert.Equal(t, time.Second, constant(1))
+	assert.Equal(t, time.Second, constant(5))
+
+	exponential := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, exponential(1))
+	assert.Equal(t, 20*time.Millisecond, exponential(2))
+	assert.Equal(t, 40*time.Millisecond, exponential(3))
+	assert.Equal(t, 50*time.Millisecond, exponential(4))
+	assert.Equal(t, 50*time.Millisecond, exponential(100))
+
+	jittered := JitteredBackoff(constant)
+	for i := 0; i < 100; i++ {
+		delay := jittered(1)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.Less(t, delay, time.Second)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(errTemporary))
+	assert.False(t, IsRetryable(Permanent(errTemporary)))
+	assert.False(t, IsRetryable(&HandlerPanicError{}))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.True(t, errors.Is(Permanent(errTemporary), errTemporary))
+
+	retryOn := RetryOn(errTemporary)
+	assert.True(t, retryOn(errTemporary))
+	assert.False(t, retryOn(errors.New("other error")))
+}
+
+func TestEventBusRetriesHandler(t *testing.T) {
+	var calls atomic.Int32
+	bus := New()
+	bus.Subscribe(Retry(failingHandler(2, errTemporary, &calls), RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     ConstantBackoff(time.Millisecond),
+	}), EventA)
+
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestEventBusRetryExhausted(t *testing.T) {
+	var calls atomic.Int32
+	bus := New()
+	bus.Subscribe(Retry(failingHandler(5, errTemporary, &calls), RetryPolicy{MaxAttempts: 3}), EventA)
+
+	err := bus.Publish(&TestEventA{})
+
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 3, retryErr.Attempts)
+	assert.ErrorIs(t, err, errTemporary)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestEventBusRetryStopsOnNonRetryableError(t *testing.T) {
+	var calls atomic.Int32
+	bus := New()
+	bus.Subscribe(Retry(failingHandler(5, errTemporary, &calls), RetryPolicy{
+		MaxAttempts: 3,
+		Retryable:   RetryOn(context.DeadlineExceeded),
+	}), EventA)
+
+	err := bus.Publish(&TestEventA{})
+
+	assert.ErrorIs(t, err, errTemporary)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestEventBusRetryStopsWhenContextIsCancelled(t *testing.T) {
+	var calls atomic.Int32
+	bus := New()
+	bus.Subscribe(Retry(failingHandler(5, errTemporary, &calls), RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     ConstantBackoff(time.Hour),
+	}), EventA)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := bus.PublishContext(ctx, &TestEventA{})
+
+	assert.ErrorIs(t, err, errTemporary)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestEventBusRetryAppliesMiddlewareToEveryAttempt(t *testing.T) {
+	var calls, middlewareCalls atomic.Int32
+	bus := New(WithHandlerMiddleware(func(next EventHandler) EventHandler {
+		return EventHandlerFunc(func(event any) error {
+			middlewareCalls.Add(1)
+			return next.Handle(event)
+		})
+	}))
+	bus.Subscribe(Retry(failingHandler(1, errTemporary, &calls), RetryPolicy{MaxAttempts: 2}), EventA)
+
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+	assert.Equal(t, int32(2), middlewareCalls.Load())
+}
+
+func TestAsyncEventBusRetriesHandler(t *testing.T) {
+	var calls atomic.Int32
+	bus := NewAsync()
+	bus.Subscribe(Retry(failingHandler(5, errTemporary, &calls), RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     ConstantBackoff(time.Millisecond),
+	}), EventA)
+
+	err := bus.PublishAsync(context.Background(), &TestEventA{}).Wait()
+
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 3, retryErr.Attempts)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestAsyncPoolRetryDoesNotOccupyWorker(t *testing.T) {
+	var calls atomic.Int32
+	handled := make(chan any, 1)
+	bus := NewAsyncPool(1, 10)
+	bus.Subscribe(Retry(failingHandler(1, errTemporary, &calls), RetryPolicy{
+		MaxAttempts: 2,
+		Backoff:     ConstantBackoff(50 * time.Millisecond),
+	}), EventA)
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		handled <- event
+		return nil
+	}), EventB)
+
+	result := bus.PublishAsync(context.Background(), &TestEventA{})
+	assert.NoError(t, bus.Publish(&TestEventB{}))
+
+	select {
+	case <-handled:
+		assert.Equal(t, int32(1), calls.Load())
+	case <-time.After(40 * time.Millisecond):
+		t.Fatal("expected the worker to handle the other event during the backoff")
+	}
+	assert.NoError(t, result.Wait())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestChanneledEventBusRetryDoesNotBlockLoop(t *testing.T) {
+	var calls atomic.Int32
+	handled := make(chan any, 1)
+	errs := make(chan error, 1)
+	bus, cancel := NewChanneldWith(New(WithErrorHandler(func(err error, event any) error {
+		errs <- err
+		return nil
+	})))
+	defer cancel()
+	bus.Subscribe(Retry(failingHandler(5, errTemporary, &calls), RetryPolicy{
+		MaxAttempts: 2,
+		Backoff:     ConstantBackoff(50 * time.Millisecond),
+	}), EventA)
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		handled <- event
+		return nil
+	}), EventB)
+
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+	assert.NoError(t, bus.Publish(&TestEventB{}))
+
+	select {
+	case <-handled:
+		assert.Equal(t, int32(1), calls.Load())
+	case <-time.After(40 * time.Millisecond):
+		t.Fatal("expected the loop to handle the next event during the backoff")
+	}
+
+	assert.NoError(t, bus.Drain(context.Background()))
+	assert.Equal(t, int32(2), calls.Load())
+	var retryErr *RetryError
+	assert.True(t, errors.As(<-errs, &retryErr))
+	assert.Equal(t, 2, retryErr.Attempts)
+}
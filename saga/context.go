@@ -0,0 +1,95 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mbict/go-eventbus/v2"
+)
+
+// ErrNoCommandBus is returned when a saga sends a command without a command bus
+var ErrNoCommandBus = errors.New("saga has no command bus")
+
+// Context is passed to the handlers of a saga instance, it carries the envelope of the handled event so the published
+// events and sent commands are caused by it
+type Context struct {
+	context.Context
+	saga      string
+	key       string
+	instance  *Instance
+	events    *eventbus.UnitOfWork
+	commands  eventbus.CommandBus
+	codec     eventbus.EventCodec
+	resolver  eventbus.EventNameResolver
+	clock     eventbus.Clock
+	completed bool
+}
+
+// Saga returns the name of the saga
+func (c *Context) Saga() string {
+	return c.saga
+}
+
+// Key returns the correlation key of the saga instance
+func (c *Context) Key() string {
+	return c.key
+}
+
+// Publish publishes the event on the bus of the saga, the event is published after the instance is saved
+func (c *Context) Publish(event any) error {
+	return c.events.PublishContext(c, event)
+}
+
+// Send sends the command on the command bus of the saga and returns its result
+func (c *Context) Send(command any) (any, error) {
+	if c.commands == nil {
+		return nil, ErrNoCommandBus
+	}
+	return c.commands.SendContext(c, command)
+}
+
+// Complete ends the saga, the instance is removed from the store when the handler succeeds
+func (c *Context) Complete() {
+	c.completed = true
+}
+
+// Timeout sets the deadline of the instance to the duration from now, the timeout handler is called when the
+// instance is still running at the deadline. A new timeout replaces the current one.
+func (c *Context) Timeout(d time.Duration) {
+	c.instance.Deadline = c.clock.Now().Add(d)
+}
+
+// CancelTimeout removes the deadline of the instance
+func (c *Context) CancelTimeout() {
+	c.instance.Deadline = time.Time{}
+}
+
+// AddCompensation adds a command that undoes a step of the saga, the compensating commands are stored with the instance
+func (c *Context) AddCompensation(command any) error {
+	payload, err := c.codec.Marshal(command)
+	if err != nil {
+		return err
+	}
+	c.instance.Compensations = append(c.instance.Compensations, Command{Name: c.resolver(command), Payload: payload})
+	return nil
+}
+
+// Compensate sends the compensating commands in the reverse order they were added and completes the saga.
+// The commands sent before an error are removed, unless the handler returns the error and the instance is not saved.
+func (c *Context) Compensate() error {
+	for len(c.instance.Compensations) > 0 {
+		last := c.instance.Compensations[len(c.instance.Compensations)-1]
+		command, err := c.codec.Unmarshal(last.Name, last.Payload)
+		if err != nil {
+			return err
+		}
+		if _, err := c.Send(command); err != nil {
+			return err
+		}
+		c.instance.Compensations = c.instance.Compensations[:len(c.instance.Compensations)-1]
+	}
+
+	c.Complete()
+	return nil
+}
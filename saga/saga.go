@@ -0,0 +1,337 @@
+// Package saga coordinates long-running workflows on the event bus. A saga declares the events that start it and
+// the events it reacts to, every event is routed to a saga instance by its correlation key. The state of the
+// instances is persisted in a store, instances can time out and send compensating commands to undo their steps.
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mbict/go-eventbus/v2"
+)
+
+// KeyFunc returns the correlation key of the saga instance the event belongs to, an empty key ignores the event
+type KeyFunc func(envelope *eventbus.Envelope) string
+
+// CorrelationKey correlates the events by the correlation id of their envelope
+func CorrelationKey(envelope *eventbus.Envelope) string {
+	return envelope.CorrelationID
+}
+
+// Handler handles an event for a saga instance, the changes to the state are saved when the handler succeeds
+type Handler[S any] func(ctx *Context, state *S, event any) error
+
+// Timeout is the event passed to the timeout handler of a saga
+type Timeout struct {
+	Saga     string
+	Key      string
+	Deadline time.Time
+}
+
+func (Timeout) EventName() eventbus.EventName {
+	return "saga.timeout"
+}
+
+type Option[S any] func(*Saga[S])
+
+// StartedBy starts a new saga instance for the event when there is no instance for its key yet.
+// When an instance exists the event is handled by the handler registered with On, or ignored.
+func StartedBy[S any](name eventbus.EventName, key KeyFunc, handler Handler[S]) Option[S] {
+	return func(s *Saga[S]) {
+		s.starts[name] = route[S]{key: key, handler: handler}
+	}
+}
+
+// On handles the event for a running saga instance, events without an instance are ignored
+func On[S any](name eventbus.EventName, key KeyFunc, handler Handler[S]) Option[S] {
+	return func(s *Saga[S]) {
+		s.handlers[name] = route[S]{key: key, handler: handler}
+	}
+}
+
+// OnTimeout handles the timeout of a saga instance, the event is a Timeout
+func OnTimeout[S any](handler Handler[S]) Option[S] {
+	return func(s *Saga[S]) {
+		s.timeout = handler
+	}
+}
+
+// WithCommandBus sets the command bus the saga sends its commands and compensating commands on
+func WithCommandBus[S any](bus eventbus.CommandBus) Option[S] {
+	return func(s *Saga[S]) {
+		s.commandBus = bus
+	}
+}
+
+// WithCodec sets the codec for the compensating commands, register the command types on the codec
+// so they are sent as their Go type. The default is a json codec without registered types.
+func WithCodec[S any](codec eventbus.EventCodec) Option[S] {
+	return func(s *Saga[S]) {
+		s.codec = codec
+	}
+}
+
+// WithEventNameResolver sets the resolver for the names of the handled events and the compensating commands
+func WithEventNameResolver[S any](resolver eventbus.EventNameResolver) Option[S] {
+	return func(s *Saga[S]) {
+		s.resolver = resolver
+	}
+}
+
+// WithNameRegistry resolves the names of the handled events and the compensating commands with the registry
+func WithNameRegistry[S any](registry *eventbus.NameRegistry) Option[S] {
+	return func(s *Saga[S]) {
+		s.resolver = registry.Name
+	}
+}
+
+// WithClock sets the clock for the timeouts, the default is the eventbus.SystemClock
+func WithClock[S any](clock eventbus.Clock) Option[S] {
+	return func(s *Saga[S]) {
+		s.clock = clock
+	}
+}
+
+type route[S any] struct {
+	key     KeyFunc
+	handler Handler[S]
+}
+
+// Saga runs the instances of a saga type with the state S, the state is persisted as json
+type Saga[S any] struct {
+	name       string
+	bus        eventbus.EventBus
+	store      Store
+	commandBus eventbus.CommandBus
+	codec      eventbus.EventCodec
+	resolver   eventbus.EventNameResolver
+	clock      eventbus.Clock
+	starts     map[eventbus.EventName]route[S]
+	handlers   map[eventbus.EventName]route[S]
+	timeout    Handler[S]
+	locks      keyLocks
+}
+
+// New creates a saga, the name identifies the instances in the store. The saga publishes its events on the bus.
+func New[S any](name string, bus eventbus.EventBus, store Store, options ...Option[S]) *Saga[S] {
+	s := &Saga[S]{
+		name:     name,
+		bus:      bus,
+		store:    store,
+		codec:    eventbus.NewJSONCodec(),
+		resolver: eventbus.ResolveEventName,
+		clock:    eventbus.SystemClock,
+		starts:   make(map[eventbus.EventName]route[S]),
+		handlers: make(map[eventbus.EventName]route[S]),
+		locks:    keyLocks{locks: make(map[string]*keyLock)},
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// Name returns the name of the saga
+func (s *Saga[S]) Name() string {
+	return s.name
+}
+
+// EventNames returns the names of the events the saga handles
+func (s *Saga[S]) EventNames() []eventbus.EventName {
+	names := make([]eventbus.EventName, 0, len(s.starts)+len(s.handlers))
+	for name := range s.starts {
+		names = append(names, name)
+	}
+	for name := range s.handlers {
+		if _, ok := s.starts[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Subscribe subscribes the saga on its bus for the events it handles
+func (s *Saga[S]) Subscribe() eventbus.Subscription {
+	return s.bus.Subscribe(s, s.EventNames()...)
+}
+
+func (s *Saga[S]) Handle(event any) error {
+	return s.HandleContext(context.Background(), event)
+}
+
+// HandleContext routes the event to its saga instance
+func (s *Saga[S]) HandleContext(ctx context.Context, event any) error {
+	envelope, ok := eventbus.EnvelopeFromContext(ctx)
+	if !ok {
+		envelope = eventbus.NewEnvelope(ctx, event, "")
+		ctx = eventbus.ContextWithEnvelope(ctx, envelope)
+	}
+
+	name := s.resolver(event)
+
+	//a running instance handles the event, otherwise the event could start a new instance
+	if route, ok := s.handlers[name]; ok {
+		if key := route.key(envelope); key != "" {
+			handled, err := s.handle(ctx, key, event, route.handler, false, nil)
+			if handled || err != nil {
+				return err
+			}
+		}
+	}
+
+	if route, ok := s.starts[name]; ok {
+		if key := route.key(envelope); key != "" {
+			_, err := s.handle(ctx, key, event, route.handler, true, nil)
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckTimeouts calls the timeout handler of the instances that are past their deadline
+func (s *Saga[S]) CheckTimeouts(ctx context.Context) error {
+	instances, err := s.store.Expired(ctx, s.name, s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, instance := range instances {
+		deadline := instance.Deadline
+		timeout := Timeout{Saga: s.name, Key: instance.Key, Deadline: deadline}
+		_, err := s.handle(ctx, instance.Key, timeout, s.timeout, false, func(instance *Instance) bool {
+			//the deadline could be changed since the instances were loaded
+			if !instance.Deadline.Equal(deadline) {
+				return false
+			}
+			instance.Deadline = time.Time{}
+			return true
+		})
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Run checks the timeouts with the interval until the context is done
+func (s *Saga[S]) Run(ctx context.Context, interval time.Duration, errorHandler func(error)) error {
+	for {
+		if err := s.CheckTimeouts(ctx); err != nil && errorHandler != nil && ctx.Err() == nil {
+			errorHandler(err)
+		}
+
+		timer := s.clock.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// handle calls the handler for the instance of the key, a start handler is only called when there is no instance yet
+// and the other handlers only when there is. The optional prepare func can skip the instance. The events published by
+// the handler are published after the instance is saved, or deleted when the handler completed the saga.
+func (s *Saga[S]) handle(ctx context.Context, key string, event any, handler Handler[S], start bool, prepare func(*Instance) bool) (bool, error) {
+	if handler == nil {
+		return false, nil
+	}
+
+	uow := eventbus.NewUnitOfWork(s.bus)
+	defer uow.Rollback()
+
+	unlock := s.locks.lock(key)
+	handled, err := s.apply(ctx, uow, key, event, handler, start, prepare)
+	unlock()
+
+	if !handled || err != nil {
+		return handled, err
+	}
+	return true, uow.Commit(ctx)
+}
+
+// apply runs the handler on the stored instance, the lock of the key must be held
+func (s *Saga[S]) apply(ctx context.Context, uow *eventbus.UnitOfWork, key string, event any, handler Handler[S], start bool, prepare func(*Instance) bool) (bool, error) {
+	instance, err := s.store.Load(ctx, s.name, key)
+	found := err == nil
+	if err != nil && !errors.Is(err, ErrInstanceNotFound) {
+		return false, err
+	}
+	if found == start || (prepare != nil && !prepare(&instance)) {
+		return false, nil
+	}
+
+	var state S
+	if found {
+		if err := json.Unmarshal(instance.State, &state); err != nil {
+			return true, err
+		}
+	} else {
+		instance = Instance{Saga: s.name, Key: key}
+	}
+
+	c := &Context{
+		Context:  ctx,
+		saga:     s.name,
+		key:      key,
+		instance: &instance,
+		events:   uow,
+		commands: s.commandBus,
+		codec:    s.codec,
+		resolver: s.resolver,
+		clock:    s.clock,
+	}
+	if err := handler(c, &state, event); err != nil {
+		return true, err
+	}
+
+	if c.completed {
+		if !found {
+			return true, nil
+		}
+		return true, s.store.Delete(ctx, s.name, key)
+	}
+
+	if instance.State, err = json.Marshal(state); err != nil {
+		return true, err
+	}
+	return true, s.store.Save(ctx, &instance)
+}
+
+// keyLocks serializes the handling of the events of a saga instance
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (l *keyLocks) lock(key string) func() {
+	l.mu.Lock()
+	kl, ok := l.locks[key]
+	if !ok {
+		kl = &keyLock{}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	kl.Lock()
+	return func() {
+		kl.Unlock()
+		l.mu.Lock()
+		kl.refs--
+		if kl.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
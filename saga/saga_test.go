@@ -0,0 +1,292 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mbict/go-eventbus/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderPlaced struct {
+	OrderID string
+}
+
+func (orderPlaced) EventName() eventbus.EventName {
+	return "order.placed"
+}
+
+type paymentReceived struct {
+	OrderID string
+}
+
+func (paymentReceived) EventName() eventbus.EventName {
+	return "payment.received"
+}
+
+type paymentFailed struct {
+	OrderID string
+}
+
+func (paymentFailed) EventName() eventbus.EventName {
+	return "payment.failed"
+}
+
+type reservePayment struct {
+	OrderID string
+}
+
+func (reservePayment) EventName() eventbus.EventName {
+	return "payment.reserve"
+}
+
+type releaseStock struct {
+	OrderID string
+}
+
+func (releaseStock) EventName() eventbus.EventName {
+	return "stock.release"
+}
+
+type cancelOrder struct {
+	OrderID string
+}
+
+func (cancelOrder) EventName() eventbus.EventName {
+	return "order.cancel"
+}
+
+type orderState struct {
+	OrderID string
+	Paid    bool
+}
+
+func orderKey(envelope *eventbus.Envelope) string {
+	switch e := envelope.Event.(type) {
+	case orderPlaced:
+		return e.OrderID
+	case paymentReceived:
+		return e.OrderID
+	case paymentFailed:
+		return e.OrderID
+	}
+	return ""
+}
+
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTimer(d time.Duration) eventbus.Timer {
+	return eventbus.SystemClock.NewTimer(d)
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type fixture struct {
+	bus      eventbus.EventBus
+	store    *MemoryStore
+	clock    *manualClock
+	saga     *Saga[orderState]
+	commands []any
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{
+		bus:   eventbus.New(),
+		store: NewMemoryStore(),
+		clock: &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	commands := eventbus.NewCommandBus()
+	record := eventbus.EventHandlerFunc(func(command any) error {
+		f.commands = append(f.commands, command)
+		return nil
+	})
+	assert.NoError(t, commands.Register(record, "payment.reserve", "stock.release", "order.cancel"))
+
+	f.saga = New[orderState]("order", f.bus, f.store,
+		WithCommandBus[orderState](commands),
+		WithCodec[orderState](eventbus.NewJSONCodec(releaseStock{}, cancelOrder{})),
+		WithClock[orderState](f.clock),
+		StartedBy("order.placed", orderKey, func(ctx *Context, state *orderState, event any) error {
+			state.OrderID = event.(orderPlaced).OrderID
+			if err := ctx.AddCompensation(releaseStock{OrderID: state.OrderID}); err != nil {
+				return err
+			}
+			if err := ctx.AddCompensation(cancelOrder{OrderID: state.OrderID}); err != nil {
+				return err
+			}
+			ctx.Timeout(time.Hour)
+			_, err := ctx.Send(reservePayment{OrderID: state.OrderID})
+			return err
+		}),
+		On("payment.received", orderKey, func(ctx *Context, state *orderState, event any) error {
+			state.Paid = true
+			ctx.CancelTimeout()
+			return nil
+		}),
+		On("payment.failed", orderKey, func(ctx *Context, state *orderState, event any) error {
+			return ctx.Compensate()
+		}),
+		OnTimeout(func(ctx *Context, state *orderState, event any) error {
+			return ctx.Compensate()
+		}),
+	)
+	f.saga.Subscribe()
+	return f
+}
+
+func (f *fixture) state(t *testing.T, key string) (orderState, bool) {
+	instance, err := f.store.Load(context.Background(), "order", key)
+	if errors.Is(err, ErrInstanceNotFound) {
+		return orderState{}, false
+	}
+	assert.NoError(t, err)
+
+	var state orderState
+	assert.NoError(t, json.Unmarshal(instance.State, &state))
+	return state, true
+}
+
+func TestSagaLifecycle(t *testing.T) {
+	f := newFixture(t)
+
+	//events without a running instance are ignored
+	assert.NoError(t, f.bus.Publish(paymentReceived{OrderID: "1"}))
+	_, ok := f.state(t, "1")
+	assert.False(t, ok)
+
+	assert.NoError(t, f.bus.Publish(orderPlaced{OrderID: "1"}))
+	assert.Equal(t, []any{reservePayment{OrderID: "1"}}, f.commands)
+	state, ok := f.state(t, "1")
+	assert.True(t, ok)
+	assert.Equal(t, orderState{OrderID: "1"}, state)
+
+	//a second start event for the same key is ignored
+	assert.NoError(t, f.bus.Publish(orderPlaced{OrderID: "1"}))
+	assert.Len(t, f.commands, 1)
+
+	assert.NoError(t, f.bus.Publish(paymentReceived{OrderID: "1"}))
+	state, _ = f.state(t, "1")
+	assert.True(t, state.Paid)
+
+	//the timeout is cancelled
+	f.clock.Advance(2 * time.Hour)
+	assert.NoError(t, f.saga.CheckTimeouts(context.Background()))
+	assert.Len(t, f.commands, 1)
+}
+
+func TestSagaCompensation(t *testing.T) {
+	f := newFixture(t)
+
+	assert.NoError(t, f.bus.Publish(orderPlaced{OrderID: "1"}))
+	assert.NoError(t, f.bus.Publish(paymentFailed{OrderID: "1"}))
+
+	assert.Equal(t, []any{
+		reservePayment{OrderID: "1"},
+		cancelOrder{OrderID: "1"},
+		releaseStock{OrderID: "1"},
+	}, f.commands)
+
+	//the saga is completed
+	_, ok := f.state(t, "1")
+	assert.False(t, ok)
+}
+
+func TestSagaTimeout(t *testing.T) {
+	f := newFixture(t)
+
+	assert.NoError(t, f.bus.Publish(orderPlaced{OrderID: "1"}))
+	assert.NoError(t, f.bus.Publish(orderPlaced{OrderID: "2"}))
+	assert.NoError(t, f.bus.Publish(paymentReceived{OrderID: "2"}))
+
+	f.clock.Advance(30 * time.Minute)
+	assert.NoError(t, f.saga.CheckTimeouts(context.Background()))
+	assert.Len(t, f.commands, 2)
+
+	f.clock.Advance(30 * time.Minute)
+	assert.NoError(t, f.saga.CheckTimeouts(context.Background()))
+	assert.Equal(t, []any{cancelOrder{OrderID: "1"}, releaseStock{OrderID: "1"}}, f.commands[2:])
+
+	_, ok := f.state(t, "1")
+	assert.False(t, ok)
+	_, ok = f.state(t, "2")
+	assert.True(t, ok)
+}
+
+func TestSagaHandlerErrorKeepsState(t *testing.T) {
+	store := NewMemoryStore()
+	bus := eventbus.New(eventbus.WithErrorHandler(func(err error, event any) error {
+		return err
+	}))
+
+	fail := errors.New("failed")
+	s := New[orderState]("order", bus, store,
+		StartedBy("order.placed", orderKey, func(ctx *Context, state *orderState, event any) error {
+			state.OrderID = event.(orderPlaced).OrderID
+			return nil
+		}),
+		On("payment.received", orderKey, func(ctx *Context, state *orderState, event any) error {
+			state.Paid = true
+			return fail
+		}),
+	)
+	s.Subscribe()
+
+	assert.NoError(t, bus.Publish(orderPlaced{OrderID: "1"}))
+	assert.ErrorIs(t, bus.Publish(paymentReceived{OrderID: "1"}), fail)
+
+	instance, err := store.Load(context.Background(), "order", "1")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"OrderID":"1","Paid":false}`, string(instance.State))
+	assert.Equal(t, 1, instance.Version)
+}
+
+func TestSagaCorrelationKey(t *testing.T) {
+	bus := eventbus.New()
+	var handled []string
+	s := New[orderState]("order", bus, NewMemoryStore(),
+		StartedBy("order.placed", CorrelationKey, func(ctx *Context, state *orderState, event any) error {
+			handled = append(handled, "start "+ctx.Key())
+			return ctx.Publish(paymentReceived{})
+		}),
+		On("payment.received", CorrelationKey, func(ctx *Context, state *orderState, event any) error {
+			handled = append(handled, "payment "+ctx.Key())
+			ctx.Complete()
+			return nil
+		}),
+	)
+	s.Subscribe()
+
+	assert.NoError(t, bus.Publish(&eventbus.Envelope{CorrelationID: "c1", Event: orderPlaced{}}))
+	assert.Equal(t, []string{"start c1", "payment c1"}, handled)
+}
+
+func TestMemoryStoreConcurrentUpdate(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	instance := &Instance{Saga: "order", Key: "1"}
+	assert.NoError(t, store.Save(ctx, instance))
+	assert.Equal(t, 1, instance.Version)
+
+	stale := Instance{Saga: "order", Key: "1"}
+	assert.ErrorIs(t, store.Save(ctx, &stale), ErrConcurrentUpdate)
+	assert.NoError(t, store.Save(ctx, instance))
+}
@@ -0,0 +1,117 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mbict/go-eventbus/v2"
+)
+
+var (
+	// ErrInstanceNotFound is returned by a store when the saga instance does not exist
+	ErrInstanceNotFound = errors.New("saga instance not found")
+
+	// ErrConcurrentUpdate is returned by a store when the saga instance is changed since it was loaded
+	ErrConcurrentUpdate = errors.New("saga instance concurrently updated")
+)
+
+// Command is a serialized compensating command of a saga instance
+type Command struct {
+	Name    eventbus.EventName `json:"name"`
+	Payload json.RawMessage    `json:"payload"`
+}
+
+// Instance is the persisted state of a running saga
+type Instance struct {
+	Saga string `json:"saga"`
+	Key  string `json:"key"`
+	// State is the json encoded state of the saga
+	State json.RawMessage `json:"state"`
+	// Compensations are the compensating commands, in the order they were added
+	Compensations []Command `json:"compensations,omitempty"`
+	// Deadline is the time the saga times out, zero without a timeout
+	Deadline time.Time `json:"deadline,omitempty"`
+	// Version is incremented by the store on every save, to detect concurrent updates
+	Version int `json:"version"`
+}
+
+// Store persists the saga instances
+type Store interface {
+	// Load returns the instance or ErrInstanceNotFound
+	Load(ctx context.Context, saga, key string) (Instance, error)
+	// Save stores the instance and increments its version. ErrConcurrentUpdate is returned when the stored version
+	// differs from the version of the instance, a new instance has version zero.
+	Save(ctx context.Context, instance *Instance) error
+	// Delete removes the instance
+	Delete(ctx context.Context, saga, key string) error
+	// Expired returns the instances of the saga with a deadline at or before the time
+	Expired(ctx context.Context, saga string, before time.Time) ([]Instance, error)
+}
+
+type instanceID struct {
+	saga string
+	key  string
+}
+
+// MemoryStore keeps the saga instances in memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	instances map[instanceID]Instance
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		instances: make(map[instanceID]Instance),
+	}
+}
+
+func (s *MemoryStore) Load(_ context.Context, saga, key string) (Instance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	instance, ok := s.instances[instanceID{saga, key}]
+	if !ok {
+		return Instance{}, ErrInstanceNotFound
+	}
+	return instance, nil
+}
+
+func (s *MemoryStore) Save(_ context.Context, instance *Instance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := instanceID{instance.Saga, instance.Key}
+	if s.instances[id].Version != instance.Version {
+		return ErrConcurrentUpdate
+	}
+
+	instance.Version++
+	stored := *instance
+	stored.Compensations = append([]Command(nil), instance.Compensations...)
+	s.instances[id] = stored
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, saga, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.instances, instanceID{saga, key})
+	return nil
+}
+
+func (s *MemoryStore) Expired(_ context.Context, saga string, before time.Time) ([]Instance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []Instance
+	for id, instance := range s.instances {
+		if id.saga == saga && !instance.Deadline.IsZero() && !instance.Deadline.After(before) {
+			res = append(res, instance)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Deadline.Before(res[j].Deadline)
+	})
+	return res, nil
+}
@@ -0,0 +1,198 @@
+package eventbus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the occurrences of a recurring scheduled event
+type Schedule interface {
+	// Next returns the first occurrence after the time, the zero time is returned when there is none
+	Next(time.Time) time.Time
+}
+
+// ParseSchedule parses a cron expression with the fields minute, hour, day of month, month and day of week, like
+// "0 9 * * mon-fri". The fields accept "*", lists, ranges, steps and the english names of the months and weekdays.
+// The descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly are accepted as well.
+//
+// The schedule runs in the location of the time passed to Next, prefix the expression with "CRON_TZ=<location> "
+// to run it in another location. The occurrences follow the wall clock of the location: an occurrence in the hour
+// skipped when daylight saving time starts is published at the end of the gap, an occurrence in the hour repeated
+// when it ends is published once.
+func ParseSchedule(spec string) (Schedule, error) {
+	var location *time.Location
+	expr := strings.TrimSpace(spec)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if rest, ok := strings.CutPrefix(expr, prefix); ok {
+			name, fields, _ := strings.Cut(rest, " ")
+			loc, err := time.LoadLocation(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+			}
+			location = loc
+			expr = strings.TrimSpace(fields)
+			break
+		}
+	}
+
+	if descriptor, ok := scheduleDescriptors[expr]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &cronSchedule{location: location}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: month: %w", spec, err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of week: %w", spec, err)
+	}
+	//sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDom = fields[2] == "*" || fields[2] == "?"
+	s.anyDow = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronSchedule holds the allowed values of every field as a bit set
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// anyDom and anyDow are set for an unrestricted day field, when both day fields are restricted a day
+	// matching either of them matches
+	anyDom, anyDow bool
+	location       *time.Location
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	location := s.location
+	if location == nil {
+		location = t.Location()
+	}
+
+	//the search runs on the wall clock, which is kept in UTC as it has no daylight saving time
+	wall := t.In(location)
+	c := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, time.UTC).Add(time.Minute)
+	for limit := c.AddDate(5, 0, 0); c.Before(limit); {
+		switch {
+		case s.month&(1<<uint(c.Month())) == 0:
+			c = time.Date(c.Year(), c.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(c):
+			c = time.Date(c.Year(), c.Month(), c.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(c.Hour())) == 0:
+			c = c.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(c.Minute())) == 0:
+			c = c.Add(time.Minute)
+		default:
+			next := time.Date(c.Year(), c.Month(), c.Day(), c.Hour(), c.Minute(), 0, 0, location)
+			//a wall clock time repeated when daylight saving time ends could lie before the time
+			if next.After(t) {
+				return next
+			}
+			c = c.Add(time.Minute)
+		}
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchesDay(c time.Time) bool {
+	dom := s.dom&(1<<uint(c.Day())) != 0
+	dow := s.dow&(1<<uint(c.Weekday())) != 0
+	if s.anyDom || s.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps to a bit set
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		expr, stepExpr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepExpr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepExpr)
+			}
+		}
+
+		from, to := min, max
+		switch lo, hi, isRange := strings.Cut(expr, "-"); {
+		case expr == "*" || expr == "?":
+		case isRange:
+			var err error
+			if from, err = parseCronValue(lo, min, max, names); err != nil {
+				return 0, err
+			}
+			if to, err = parseCronValue(hi, min, max, names); err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, fmt.Errorf("invalid range %q", expr)
+			}
+		default:
+			value, err := parseCronValue(expr, min, max, names)
+			if err != nil {
+				return 0, err
+			}
+			from = value
+			//a value with a step runs from the value to the end of the range
+			if !hasStep {
+				to = value
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("invalid value %q, expected %d-%d", value, min, max)
+	}
+	return v, nil
+}
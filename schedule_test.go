@@ -0,0 +1,83 @@
+package eventbus
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	for _, spec := range []string{
+		"* * * * *",
+		"*/15 9-17 * * mon-fri",
+		"0 0 1,15 jan,jul *",
+		"5/10 * * * 7",
+		"@daily",
+		"CRON_TZ=Europe/Amsterdam 0 9 * * *",
+	} {
+		_, err := ParseSchedule(spec)
+		assert.NoError(t, err, spec)
+	}
+
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"@sometimes",
+		"CRON_TZ=Nowhere/Town 0 9 * * *",
+	} {
+		_, err := ParseSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	assert.NoError(t, err)
+	at := func(year int, month time.Month, day, hour, minute int, loc *time.Location) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := map[string]struct {
+		spec     string
+		from     time.Time
+		expected time.Time
+	}{
+		"every minute":         {"* * * * *", at(2024, 1, 1, 12, 0, time.UTC).Add(30 * time.Second), at(2024, 1, 1, 12, 1, time.UTC)},
+		"daily later today":    {"0 9 * * *", at(2024, 1, 1, 8, 0, time.UTC), at(2024, 1, 1, 9, 0, time.UTC)},
+		"daily tomorrow":       {"0 9 * * *", at(2024, 1, 1, 9, 0, time.UTC), at(2024, 1, 2, 9, 0, time.UTC)},
+		"weekdays":             {"0 9 * * mon-fri", at(2024, 1, 5, 10, 0, time.UTC), at(2024, 1, 8, 9, 0, time.UTC)},
+		"sunday as 7":          {"0 0 * * 7", at(2024, 1, 1, 0, 0, time.UTC), at(2024, 1, 7, 0, 0, time.UTC)},
+		"day of month or week": {"0 0 13 * fri", at(2024, 9, 1, 0, 0, time.UTC), at(2024, 9, 6, 0, 0, time.UTC)},
+		"leap day":             {"0 0 29 feb *", at(2024, 3, 1, 0, 0, time.UTC), at(2028, 2, 29, 0, 0, time.UTC)},
+		"step in range":        {"*/20 10 * * *", at(2024, 1, 1, 10, 20, time.UTC), at(2024, 1, 1, 10, 40, time.UTC)},
+		"location of time":     {"0 9 * * *", at(2024, 1, 1, 10, 0, amsterdam), at(2024, 1, 2, 9, 0, amsterdam)},
+		"cron time zone":       {"CRON_TZ=Europe/Amsterdam 0 9 * * *", at(2024, 1, 1, 0, 0, time.UTC), at(2024, 1, 1, 8, 0, time.UTC)},
+		//the wall clock is followed across daylight saving time
+		"dst start":          {"0 9 * * *", at(2024, 3, 30, 9, 0, amsterdam), at(2024, 3, 31, 9, 0, amsterdam)},
+		"dst end":            {"0 9 * * *", at(2024, 10, 26, 9, 0, amsterdam), at(2024, 10, 27, 9, 0, amsterdam)},
+		"skipped hour":       {"30 2 * * *", at(2024, 3, 31, 1, 0, amsterdam), at(2024, 3, 31, 3, 30, amsterdam)},
+		"repeated hour once": {"30 2 * * *", at(2024, 10, 27, 2, 30, amsterdam), at(2024, 10, 28, 2, 30, amsterdam)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			schedule, err := ParseSchedule(test.spec)
+			if assert.NoError(t, err) {
+				assert.True(t, test.expected.Equal(schedule.Next(test.from)), "expected %s, got %s", test.expected, schedule.Next(test.from))
+			}
+		})
+	}
+
+	//the day never occurs
+	schedule, _ := ParseSchedule("0 0 30 feb *")
+	assert.True(t, schedule.Next(at(2024, 1, 1, 0, 0, time.UTC)).IsZero())
+}
@@ -0,0 +1,403 @@
+package eventbus
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrScheduleNotFound is returned when cancelling a scheduled event that does not exist
+var ErrScheduleNotFound = errors.New("scheduled event not found")
+
+// Clock provides the time to the scheduler, replace it in tests to control the time
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a clock
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// SystemClock is the clock of the system
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// ScheduledEvent is an event waiting in the scheduler to be published
+type ScheduledEvent struct {
+	ID        string
+	EventName EventName
+	At        time.Time
+	// Every is the interval of a recurring event, zero for an event that is published once
+	Every time.Duration
+	// Schedule is the expression of a recurring event published on a schedule, see ParseSchedule
+	Schedule string
+	Envelope *Envelope
+}
+
+// recurring reports if the event is published more than once
+func (e ScheduledEvent) recurring() bool {
+	return e.Every > 0 || e.Schedule != ""
+}
+
+// ScheduleStore persists the scheduled events so they survive a restart
+type ScheduleStore interface {
+	// Save stores the scheduled event, a scheduled event with the same id is replaced
+	Save(ctx context.Context, event ScheduledEvent) error
+	// Delete removes the scheduled event
+	Delete(ctx context.Context, id string) error
+	// List returns all the scheduled events
+	List(ctx context.Context) ([]ScheduledEvent, error)
+}
+
+type SchedulerOption func(*Scheduler)
+
+// WithClock sets the clock of the scheduler, the default is the SystemClock
+func WithClock(clock Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+// WithScheduleStore persists the scheduled events in the store
+func WithScheduleStore(store ScheduleStore) SchedulerOption {
+	return func(s *Scheduler) {
+		s.store = store
+	}
+}
+
+// WithScheduleErrorHandler sets the handler for the errors of publishing a scheduled event and of the store,
+// by default the errors are ignored
+func WithScheduleErrorHandler(handler func(ScheduledEvent, error)) SchedulerOption {
+	return func(s *Scheduler) {
+		s.errorHandler = handler
+	}
+}
+
+// Scheduler publishes events on the bus at a later time. The events are published when the scheduler runs,
+// events that are due while the scheduler is not running are published as soon as it runs.
+type Scheduler struct {
+	bus          EventBus
+	clock        Clock
+	store        ScheduleStore
+	errorHandler func(ScheduledEvent, error)
+	mu           sync.Mutex
+	queue        scheduleQueue
+	entries      map[string]*scheduleEntry
+	seq          uint64
+	wake         chan struct{}
+}
+
+// NewScheduler creates a scheduler publishing on the bus, the events in the store are scheduled again
+func NewScheduler(bus EventBus, options ...SchedulerOption) (*Scheduler, error) {
+	s := &Scheduler{
+		bus:     bus,
+		clock:   SystemClock,
+		entries: make(map[string]*scheduleEntry),
+		wake:    make(chan struct{}, 1),
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.store != nil {
+		events, err := s.store.List(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			var schedule Schedule
+			if event.Schedule != "" {
+				if schedule, err = ParseSchedule(event.Schedule); err != nil {
+					return nil, err
+				}
+			}
+			s.push(event, schedule)
+		}
+	}
+	return s, nil
+}
+
+// PublishAt schedules the event to be published at the time and returns the id to cancel it.
+// The envelope of the event is created when it is scheduled, the event is caused by the envelope in the context.
+func (s *Scheduler) PublishAt(ctx context.Context, at time.Time, event any) (string, error) {
+	return s.schedule(ctx, ScheduledEvent{At: at}, nil, event)
+}
+
+// PublishAfter schedules the event to be published after the duration and returns the id to cancel it
+func (s *Scheduler) PublishAfter(ctx context.Context, d time.Duration, event any) (string, error) {
+	return s.schedule(ctx, ScheduledEvent{At: s.clock.Now().Add(d)}, nil, event)
+}
+
+// PublishEvery schedules the event to be published every interval, until it is cancelled.
+// Every occurrence is published with a new envelope id, occurrences missed while the scheduler was not running
+// are published once.
+func (s *Scheduler) PublishEvery(ctx context.Context, interval time.Duration, event any) (string, error) {
+	if interval <= 0 {
+		return "", errors.New("interval must be positive")
+	}
+	return s.schedule(ctx, ScheduledEvent{At: s.clock.Now().Add(interval), Every: interval}, nil, event)
+}
+
+// PublishSchedule publishes the event on the occurrences of the schedule expression, until it is cancelled.
+// The expression is parsed by ParseSchedule, for example "0 9 * * *" publishes the event every day at 09:00.
+// Every occurrence is published with a new envelope id, occurrences missed while the scheduler was not running
+// are published once.
+func (s *Scheduler) PublishSchedule(ctx context.Context, spec string, event any) (string, error) {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return "", err
+	}
+	at := schedule.Next(s.clock.Now())
+	if at.IsZero() {
+		return "", fmt.Errorf("the schedule %q has no occurrence", spec)
+	}
+	return s.schedule(ctx, ScheduledEvent{At: at, Schedule: spec}, schedule, event)
+}
+
+func (s *Scheduler) schedule(ctx context.Context, scheduled ScheduledEvent, schedule Schedule, event any) (string, error) {
+	envelope := newEnvelope(ctx, event, "")
+	//the event occurs when it is published
+	envelope.OccurredAt = time.Time{}
+
+	name, err := busEventName(s.bus, envelope.Event)
+	if err != nil {
+		return "", err
+	}
+	scheduled.ID = newEventID()
+	scheduled.EventName = name
+	scheduled.Envelope = envelope
+
+	s.mu.Lock()
+	if s.store != nil {
+		if err := s.store.Save(ctx, scheduled); err != nil {
+			s.mu.Unlock()
+			return "", err
+		}
+	}
+	s.push(scheduled, schedule)
+	s.mu.Unlock()
+	s.notify()
+	return scheduled.ID, nil
+}
+
+// Cancel removes the scheduled event, a recurring event is not published anymore
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[id]
+	if !ok {
+		return ErrScheduleNotFound
+	}
+	heap.Remove(&s.queue, entry.index)
+	delete(s.entries, id)
+	s.notify()
+
+	if s.store != nil {
+		return s.store.Delete(ctx, id)
+	}
+	return nil
+}
+
+// Run publishes the events when they are due, until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		next, ok := s.publishDue(ctx)
+
+		var timer Timer
+		var timeout <-chan time.Time
+		if ok {
+			timer = s.clock.NewTimer(next.Sub(s.clock.Now()))
+			timeout = timer.C()
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-timeout:
+		case <-s.wake:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// publishDue publishes the events that are due and returns the time the next event is due
+func (s *Scheduler) publishDue(ctx context.Context) (time.Time, bool) {
+	for ctx.Err() == nil {
+		now := s.clock.Now()
+
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return time.Time{}, false
+		}
+		entry := s.queue[0]
+		if entry.event.At.After(now) {
+			s.mu.Unlock()
+			return entry.event.At, true
+		}
+
+		scheduled := entry.event
+		//the next occurrence, missed occurrences are skipped
+		next := entry.next(now)
+		if !next.IsZero() {
+			entry.event.At = next
+			heap.Fix(&s.queue, entry.index)
+		} else {
+			heap.Pop(&s.queue)
+			delete(s.entries, scheduled.ID)
+		}
+		s.mu.Unlock()
+
+		s.publish(ctx, scheduled)
+
+		//the store is updated after the event is published, so it is published at least once
+		if err := s.published(ctx, scheduled, next.IsZero()); err != nil {
+			s.handleError(scheduled, err)
+		}
+	}
+	return time.Time{}, false
+}
+
+// published removes the published event from the store when it was the last occurrence, or stores the next
+// occurrence of a recurring event
+func (s *Scheduler) published(ctx context.Context, scheduled ScheduledEvent, last bool) error {
+	if s.store == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last {
+		return s.store.Delete(ctx, scheduled.ID)
+	}
+	if entry, ok := s.entries[scheduled.ID]; ok {
+		return s.store.Save(ctx, entry.event)
+	}
+	//cancelled while it was published
+	return nil
+}
+
+func (s *Scheduler) publish(ctx context.Context, scheduled ScheduledEvent) {
+	envelope := *scheduled.Envelope
+	envelope.OccurredAt = scheduled.At
+	if scheduled.recurring() {
+		//every occurrence is a new event
+		if envelope.CorrelationID == envelope.ID {
+			envelope.CorrelationID = ""
+		}
+		envelope.ID = ""
+	}
+
+	if err := s.bus.PublishContext(ctx, &envelope); err != nil {
+		s.handleError(scheduled, err)
+	}
+}
+
+func (s *Scheduler) handleError(scheduled ScheduledEvent, err error) {
+	if s.errorHandler != nil {
+		s.errorHandler(scheduled, err)
+	}
+}
+
+// push adds the scheduled event to the queue, the lock must be held
+func (s *Scheduler) push(event ScheduledEvent, schedule Schedule) {
+	s.seq++
+	entry := &scheduleEntry{event: event, schedule: schedule, seq: s.seq}
+	s.entries[event.ID] = entry
+	heap.Push(&s.queue, entry)
+}
+
+// notify wakes up the run loop to pick up the changed queue
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+type scheduleEntry struct {
+	event    ScheduledEvent
+	schedule Schedule
+	seq      uint64
+	index    int
+}
+
+// next returns the first occurrence after the time, the zero time is returned when the event is not recurring
+// or its schedule has ended
+func (e *scheduleEntry) next(now time.Time) time.Time {
+	switch {
+	case e.schedule != nil:
+		return e.schedule.Next(now)
+	case e.event.Every > 0:
+		at := e.event.At
+		if at.After(now) {
+			return at
+		}
+		//the missed occurrences are skipped at once
+		return at.Add((now.Sub(at)/e.event.Every + 1) * e.event.Every)
+	}
+	return time.Time{}
+}
+
+// scheduleQueue is a heap of the scheduled events ordered by the time they are due, in the order they were scheduled
+type scheduleQueue []*scheduleEntry
+
+func (q scheduleQueue) Len() int {
+	return len(q)
+}
+
+func (q scheduleQueue) Less(i, j int) bool {
+	if q[i].event.At.Equal(q[j].event.At) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].event.At.Before(q[j].event.At)
+}
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x any) {
+	entry := x.(*scheduleEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *scheduleQueue) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return entry
+}
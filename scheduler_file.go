@@ -0,0 +1,139 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileScheduleStore stores the scheduled events in a json file, the file is rewritten on every change.
+// The events are serialized with the codec, register the event types on the codec to publish them as their Go type.
+type FileScheduleStore struct {
+	mu     sync.Mutex
+	path   string
+	codec  EventCodec
+	events []fileScheduledEvent
+}
+
+type fileScheduledEvent struct {
+	ID        string          `json:"id"`
+	EventName EventName       `json:"event_name"`
+	At        time.Time       `json:"at"`
+	Every     time.Duration   `json:"every,omitempty"`
+	Schedule  string          `json:"schedule,omitempty"`
+	Envelope  Envelope        `json:"envelope"`
+	Event     json.RawMessage `json:"event"`
+}
+
+// NewFileScheduleStore opens the schedule store in the file, the file is created on the first scheduled event
+func NewFileScheduleStore(path string, codec EventCodec) (*FileScheduleStore, error) {
+	s := &FileScheduleStore{
+		path:  path,
+		codec: codec,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.events); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileScheduleStore) Save(_ context.Context, scheduled ScheduledEvent) error {
+	event, err := s.codec.Marshal(scheduled.Envelope.Event)
+	if err != nil {
+		return err
+	}
+
+	record := fileScheduledEvent{
+		ID:        scheduled.ID,
+		EventName: scheduled.EventName,
+		At:        scheduled.At,
+		Every:     scheduled.Every,
+		Schedule:  scheduled.Schedule,
+		Envelope:  *scheduled.Envelope,
+		Event:     event,
+	}
+	record.Envelope.Event = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := append([]fileScheduledEvent(nil), s.events...)
+	if i := s.index(scheduled.ID); i >= 0 {
+		events[i] = record
+	} else {
+		events = append(events, record)
+	}
+	return s.write(events)
+}
+
+func (s *FileScheduleStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return nil
+	}
+	events := append(append([]fileScheduledEvent(nil), s.events[:i]...), s.events[i+1:]...)
+	return s.write(events)
+}
+
+func (s *FileScheduleStore) List(_ context.Context) ([]ScheduledEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]ScheduledEvent, 0, len(s.events))
+	for _, record := range s.events {
+		event, err := s.codec.Unmarshal(record.EventName, record.Event)
+		if err != nil {
+			return nil, err
+		}
+
+		envelope := record.Envelope
+		envelope.Event = event
+		res = append(res, ScheduledEvent{
+			ID:        record.ID,
+			EventName: record.EventName,
+			At:        record.At,
+			Every:     record.Every,
+			Schedule:  record.Schedule,
+			Envelope:  &envelope,
+		})
+	}
+	return res, nil
+}
+
+func (s *FileScheduleStore) index(id string) int {
+	for i := range s.events {
+		if s.events[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// write replaces the file atomically, the events in memory are only replaced when the file is written
+func (s *FileScheduleStore) write(events []fileScheduledEvent) error {
+	if events == nil {
+		events = []fileScheduledEvent{}
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return err
+	}
+
+	s.events = events
+	return nil
+}
@@ -0,0 +1,328 @@
+package eventbus
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+	} else {
+		c.timers = append(c.timers, t)
+	}
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			timers = append(timers, t)
+		} else {
+			t.c <- c.now
+		}
+	}
+	c.timers = timers
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	return true
+}
+
+func recordingBus() (EventBus, *[]*Envelope) {
+	var published []*Envelope
+	bus := New()
+	bus.Subscribe(EnvelopeHandlerFunc(func(ctx context.Context, envelope *Envelope) error {
+		published = append(published, envelope)
+		return nil
+	}), "*")
+	return bus, &published
+}
+
+func TestSchedulerPublishesDueEvents(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus, published := recordingBus()
+	s, err := NewScheduler(bus, WithClock(clock))
+	assert.NoError(t, err)
+
+	_, err = s.PublishAfter(ctx, 2*time.Hour, namedEvent("late"))
+	assert.NoError(t, err)
+	_, err = s.PublishAt(ctx, clock.Now().Add(time.Hour), namedEvent("early"))
+	assert.NoError(t, err)
+
+	next, ok := s.publishDue(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, clock.Now().Add(time.Hour), next)
+	assert.Empty(t, *published)
+
+	clock.Advance(time.Hour)
+	s.publishDue(ctx)
+	if assert.Len(t, *published, 1) {
+		assert.Equal(t, namedEvent("early"), (*published)[0].Event)
+		assert.Equal(t, clock.Now(), (*published)[0].OccurredAt)
+	}
+
+	clock.Advance(time.Hour)
+	_, ok = s.publishDue(ctx)
+	assert.False(t, ok)
+	assert.Len(t, *published, 2)
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus, published := recordingBus()
+	s, _ := NewScheduler(bus, WithClock(clock))
+
+	id, _ := s.PublishAfter(ctx, time.Minute, namedEvent("reminder"))
+	assert.NoError(t, s.Cancel(ctx, id))
+	assert.ErrorIs(t, s.Cancel(ctx, id), ErrScheduleNotFound)
+
+	clock.Advance(time.Minute)
+	s.publishDue(ctx)
+	assert.Empty(t, *published)
+}
+
+func TestSchedulerPublishEvery(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus, published := recordingBus()
+	s, _ := NewScheduler(bus, WithClock(clock))
+
+	id, err := s.PublishEvery(ctx, time.Minute, namedEvent("tick"))
+	assert.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	s.publishDue(ctx)
+	clock.Advance(time.Minute)
+	s.publishDue(ctx)
+
+	//missed occurrences are published once
+	clock.Advance(10 * time.Minute)
+	next, _ := s.publishDue(ctx)
+	assert.Equal(t, clock.Now().Add(time.Minute), next)
+
+	if assert.Len(t, *published, 3) {
+		assert.NotEqual(t, (*published)[0].ID, (*published)[1].ID)
+	}
+
+	assert.NoError(t, s.Cancel(ctx, id))
+	clock.Advance(time.Minute)
+	s.publishDue(ctx)
+	assert.Len(t, *published, 3)
+}
+
+func TestSchedulerRun(t *testing.T) {
+	clock := newFakeClock()
+	bus := New()
+	published := make(chan any, 1)
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		published <- event
+		return nil
+	}), "*")
+
+	s, _ := NewScheduler(bus, WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	_, _ = s.PublishAfter(ctx, time.Hour, namedEvent("reminder"))
+	clock.Advance(time.Hour)
+
+	select {
+	case event := <-published:
+		assert.Equal(t, namedEvent("reminder"), event)
+	case <-time.After(time.Second):
+		t.Fatal("event not published")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestSchedulerFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	clock := newFakeClock()
+
+	store, err := NewFileScheduleStore(path, NewJSONCodec(dlqEvent{}))
+	assert.NoError(t, err)
+	s, _ := NewScheduler(New(), WithClock(clock), WithScheduleStore(store))
+	_, _ = s.PublishAfter(ctx, time.Hour, dlqEvent{OrderID: 1})
+	cancelled, _ := s.PublishAfter(ctx, time.Hour, dlqEvent{OrderID: 2})
+	_, _ = s.PublishEvery(ctx, time.Hour, dlqEvent{OrderID: 3})
+	assert.NoError(t, s.Cancel(ctx, cancelled))
+
+	//restart
+	var orders []int
+	bus := New()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		orders = append(orders, event.(dlqEvent).OrderID)
+		return nil
+	}), "order.failed")
+
+	store, err = NewFileScheduleStore(path, NewJSONCodec(dlqEvent{}))
+	assert.NoError(t, err)
+	s, err = NewScheduler(bus, WithClock(clock), WithScheduleStore(store))
+	assert.NoError(t, err)
+
+	clock.Advance(time.Hour)
+	s.publishDue(ctx)
+	assert.Equal(t, []int{1, 3}, orders)
+
+	//only the recurring event remains, with its next occurrence
+	events, err := store.List(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, clock.Now().Add(time.Hour), events[0].At)
+		assert.Equal(t, time.Hour, events[0].Every)
+	}
+}
+
+func TestSchedulerPublishSchedule(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus, published := recordingBus()
+	s, _ := NewScheduler(bus, WithClock(clock))
+
+	_, err := s.PublishSchedule(ctx, "not a schedule", namedEvent("report"))
+	assert.Error(t, err)
+	_, err = s.PublishSchedule(ctx, "0 0 30 feb *", namedEvent("report"))
+	assert.Error(t, err)
+
+	id, err := s.PublishSchedule(ctx, "0 9 * * *", namedEvent("report"))
+	assert.NoError(t, err)
+
+	//every day at 09:00, the clock starts at noon
+	next, _ := s.publishDue(ctx)
+	assert.Equal(t, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), next)
+
+	clock.Advance(21 * time.Hour)
+	next, _ = s.publishDue(ctx)
+	assert.Equal(t, time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), next)
+
+	//missed occurrences are published once
+	clock.Advance(72 * time.Hour)
+	next, _ = s.publishDue(ctx)
+	assert.Equal(t, time.Date(2024, 1, 6, 9, 0, 0, 0, time.UTC), next)
+
+	if assert.Len(t, *published, 2) {
+		assert.Equal(t, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), (*published)[0].OccurredAt)
+		assert.NotEqual(t, (*published)[0].ID, (*published)[1].ID)
+	}
+
+	assert.NoError(t, s.Cancel(ctx, id))
+}
+
+func TestSchedulerScheduleFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	clock := newFakeClock()
+
+	store, _ := NewFileScheduleStore(path, NewJSONCodec(dlqEvent{}))
+	s, _ := NewScheduler(New(), WithClock(clock), WithScheduleStore(store))
+	_, err := s.PublishSchedule(ctx, "30 12 * * *", dlqEvent{OrderID: 1})
+	assert.NoError(t, err)
+
+	//restart
+	var orders []int
+	bus := New()
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		orders = append(orders, event.(dlqEvent).OrderID)
+		return nil
+	}), "order.failed")
+
+	store, _ = NewFileScheduleStore(path, NewJSONCodec(dlqEvent{}))
+	s, err = NewScheduler(bus, WithClock(clock), WithScheduleStore(store))
+	assert.NoError(t, err)
+
+	clock.Advance(30 * time.Minute)
+	s.publishDue(ctx)
+	assert.Equal(t, []int{1}, orders)
+
+	events, err := store.List(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, time.Date(2024, 1, 2, 12, 30, 0, 0, time.UTC), events[0].At)
+		assert.Equal(t, "30 12 * * *", events[0].Schedule)
+	}
+}
+
+func TestSchedulerNamesEventsWithBusResolver(t *testing.T) {
+	ctx := context.Background()
+	registry := NewNameRegistry(nil)
+	assert.NoError(t, registry.Register(dlqEvent{}, "orders.retry"))
+
+	channeled, cancel := NewChanneldWith(New(WithNameRegistry(registry)))
+	defer cancel()
+
+	buses := map[string]EventBus{
+		"bus":        New(WithNameRegistry(registry)),
+		"async bus":  NewAsync(WithNameRegistry(registry)),
+		"concurrent": NewConcurrent(WithNameRegistry(registry)),
+		"channeled":  channeled,
+	}
+	for name, bus := range buses {
+		t.Run(name, func(t *testing.T) {
+			s, _ := NewScheduler(bus, WithClock(newFakeClock()))
+			id, err := s.PublishAfter(ctx, time.Hour, dlqEvent{OrderID: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, "orders.retry", s.entries[id].event.EventName)
+		})
+	}
+}
+
+func TestSchedulerPublishEveryAfterLongDowntime(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus, published := recordingBus()
+	s, _ := NewScheduler(bus, WithClock(clock))
+
+	_, err := s.PublishEvery(ctx, time.Millisecond, namedEvent("tick"))
+	assert.NoError(t, err)
+
+	clock.Advance(365*24*time.Hour + time.Millisecond/2)
+	next, _ := s.publishDue(ctx)
+	assert.Equal(t, clock.Now().Add(time.Millisecond/2), next)
+	assert.Len(t, *published, 1)
+}
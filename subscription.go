@@ -0,0 +1,191 @@
+package eventbus
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Subscription is the handle of a single handler registration on a bus.
+type Subscription interface {
+	// Unsubscribe removes this registration from the bus, calling it more than once has no effect.
+	Unsubscribe()
+	// Active reports if the registration is still subscribed to any event.
+	Active() bool
+}
+
+type subscription struct {
+	handler     EventHandler
+	name        string
+	dispatch    EventHandler
+	retry       *RetryPolicy
+	events      []EventName
+	active      atomic.Bool
+	unsubscribe func(*subscription)
+}
+
+func (s *subscription) Unsubscribe() {
+	if s.active.Load() {
+		s.unsubscribe(s)
+	}
+}
+
+func (s *subscription) Active() bool {
+	return s.active.Load()
+}
+
+// lockedSubscription guards the unsubscribe of a subscription with the lock of a wrapping bus
+type lockedSubscription struct {
+	Subscription
+	mu sync.Locker
+}
+
+func (s *lockedSubscription) Unsubscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Subscription.Unsubscribe()
+}
+
+// findSubscription looks up the subscription on a bus, wrapping buses delegate to the bus they wrap
+func findSubscription(bus EventBus, handlerName string) (*subscription, bool) {
+	if finder, ok := bus.(subscriptionFinder); ok {
+		return finder.findSubscription(handlerName)
+	}
+	return nil, false
+}
+
+type eventHandlers []*subscription
+type eventChannels map[EventName]eventHandlers
+
+// handlerRegistry keeps track of the subscriptions per event name. The registry is not safe for concurrent use,
+// slices are copied on write, so a slice returned by lookup can be iterated while handlers are (un)subscribed.
+// Subscriptions for a pattern are also kept in a trie, so the matching patterns are found without scanning all of them.
+type handlerRegistry struct {
+	handlers   eventChannels
+	patterns   topicNode
+	middleware handlerMiddlewares
+}
+
+func newHandlerRegistry() handlerRegistry {
+	return handlerRegistry{
+		handlers: make(eventChannels),
+	}
+}
+
+func (r *handlerRegistry) subscribe(handler EventHandler, events []EventName, unsubscribe func(*subscription)) *subscription {
+	if len(events) == 0 {
+		events = []EventName{"*"}
+	}
+
+	s := &subscription{
+		handler:     handler,
+		name:        HandlerName(handler),
+		dispatch:    r.middleware.then(handler),
+		events:      append([]EventName(nil), events...),
+		unsubscribe: unsubscribe,
+	}
+
+	//the bus takes care of the retries, so the middleware is applied to every attempt
+	if rh, ok := handler.(*retryHandler); ok {
+		s.dispatch = r.middleware.then(rh.handler)
+		s.retry = &rh.policy
+	}
+	s.active.Store(true)
+
+	for _, eventType := range events {
+		r.set(eventType, append(r.handlers[eventType][:len(r.handlers[eventType]):len(r.handlers[eventType])], s))
+	}
+	return s
+}
+
+func (r *handlerRegistry) set(eventType EventName, handlers eventHandlers) {
+	if len(handlers) == 0 {
+		delete(r.handlers, eventType)
+	} else {
+		r.handlers[eventType] = handlers
+	}
+
+	if isTopicPattern(eventType) {
+		r.patterns.set(eventType, handlers)
+	}
+}
+
+// addHandlerMiddleware adds middleware that wraps the handlers that are subscribed after it is added
+func (r *handlerRegistry) addHandlerMiddleware(middleware ...HandlerMiddleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// remove removes the subscription from all the events it is registered for
+func (r *handlerRegistry) remove(s *subscription) {
+	for _, eventType := range s.events {
+		r.removeFromEvent(eventType, func(sub *subscription) bool { return sub == s })
+	}
+}
+
+// unsubscribeHandler removes all the subscriptions of the handler for the events, or from all events when none are given.
+// The handlers are compared by value, as a func value is not comparable, two wrapped closures are considered equal.
+func (r *handlerRegistry) unsubscribeHandler(handler EventHandler, events []EventName) {
+	match := func(sub *subscription) bool {
+		return reflect.ValueOf(sub.handler) == reflect.ValueOf(handler)
+	}
+
+	if len(events) == 0 {
+		for eventType := range r.handlers {
+			r.removeFromEvent(eventType, match)
+		}
+	}
+
+	for _, eventType := range events {
+		r.removeFromEvent(eventType, match)
+	}
+}
+
+func (r *handlerRegistry) removeFromEvent(eventType EventName, match func(*subscription) bool) {
+	eh := r.handlers[eventType]
+	res := make(eventHandlers, 0, len(eh))
+	for _, sub := range eh {
+		if !match(sub) {
+			res = append(res, sub)
+			continue
+		}
+		sub.removeEvent(eventType)
+	}
+
+	if len(res) != len(eh) {
+		r.set(eventType, res)
+	}
+}
+
+func (s *subscription) removeEvent(eventType EventName) {
+	for i := range s.events {
+		if s.events[i] == eventType {
+			s.events = append(s.events[:i:i], s.events[i+1:]...)
+			break
+		}
+	}
+	if len(s.events) == 0 {
+		s.active.Store(false)
+	}
+}
+
+// findSubscription returns a subscription of the handler with the name
+func (r *handlerRegistry) findSubscription(handlerName string) (*subscription, bool) {
+	for _, subs := range r.handlers {
+		for _, sub := range subs {
+			if sub.name == handlerName {
+				return sub, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// lookup returns the subscriptions for the exact event name
+func (r *handlerRegistry) lookup(eventType EventName) eventHandlers {
+	return r.handlers[eventType]
+}
+
+// match returns the subscriptions of the patterns that match the event name
+func (r *handlerRegistry) match(eventType EventName) eventHandlers {
+	return r.patterns.match(eventType)
+}
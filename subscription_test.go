@@ -0,0 +1,109 @@
+package eventbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionUnsubscribe(t *testing.T) {
+	buses := map[string]func() EventBus{
+		"sync":       func() EventBus { return New() },
+		"concurrent": func() EventBus { return NewConcurrent() },
+		"async":      func() EventBus { return NewAsync() },
+		"channeled": func() EventBus {
+			bus, cancel := NewChanneldWith(New())
+			t.Cleanup(cancel)
+			return bus
+		},
+	}
+
+	for name, newBus := range buses {
+		t.Run(name, func(t *testing.T) {
+			handled := make(chan string, 10)
+			handler := func(id string) EventHandler {
+				return EventHandlerFunc(func(event any) error {
+					handled <- id
+					return nil
+				})
+			}
+
+			bus := newBus()
+			sub1 := bus.Subscribe(handler("1"), EventA)
+			sub2 := bus.Subscribe(handler("2"), EventA)
+
+			sub1.Unsubscribe()
+			sub1.Unsubscribe()
+
+			assert.False(t, sub1.Active())
+			assert.True(t, sub2.Active())
+			assert.NoError(t, bus.Publish(&TestEventA{}))
+
+			select {
+			case id := <-handled:
+				assert.Equal(t, "2", id)
+			case <-time.After(time.Second):
+				t.Fatal("expected the event to be handled")
+			}
+
+			select {
+			case id := <-handled:
+				t.Fatalf("expected one handler to be called, but also got handler %s", id)
+			case <-time.After(10 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestSubscriptionDuplicateRegistrationsAreRemovedIndividually(t *testing.T) {
+	event := &TestEventA{}
+	handler := EventHandlerFunc(func(event any) error {
+		event.(*TestEventA).Handled++
+		return nil
+	})
+
+	bus := New()
+	sub1 := bus.Subscribe(handler, EventA)
+	sub2 := bus.Subscribe(handler, EventA)
+
+	sub1.Unsubscribe()
+	assert.NoError(t, bus.Publish(event))
+
+	assert.Equal(t, 1, event.Handled)
+	assert.True(t, sub2.Active())
+}
+
+func TestSubscriptionInactiveAfterHandlerUnsubscribe(t *testing.T) {
+	handler := EventHandlerFunc(func(event any) error { return nil })
+
+	bus := New()
+	sub := bus.Subscribe(handler, EventA, EventB)
+
+	bus.Unsubscribe(handler, EventA)
+	assert.True(t, sub.Active())
+
+	bus.Unsubscribe(handler, EventB)
+	assert.False(t, sub.Active())
+}
+
+func TestSubscriptionUnsubscribeDuringPublish(t *testing.T) {
+	event := &TestEventA{}
+	bus := New()
+
+	var sub Subscription
+	sub = bus.Subscribe(EventHandlerFunc(func(e any) error {
+		e.(*TestEventA).Handled++
+		sub.Unsubscribe()
+		return nil
+	}), EventA)
+	bus.Subscribe(EventHandlerFunc(func(e any) error {
+		e.(*TestEventA).Handled++
+		return nil
+	}), EventA)
+
+	assert.NoError(t, bus.Publish(event))
+	assert.NoError(t, bus.Publish(event))
+
+	assert.Equal(t, 3, event.Handled)
+}
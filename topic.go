@@ -0,0 +1,95 @@
+package eventbus
+
+import "strings"
+
+const (
+	topicSeparator = "."
+	// topicWildcard matches exactly one token of a dotted event name
+	topicWildcard = "*"
+	// topicTail matches one or more tokens at the end of a dotted event name
+	topicTail = ">"
+)
+
+// isTopicPattern reports if the event name is a hierarchical pattern like "order.*" or "billing.>".
+// The bare "*" is the catch-all and not a pattern.
+func isTopicPattern(name EventName) bool {
+	if name == "*" {
+		return false
+	}
+	tokens := strings.Split(name, topicSeparator)
+	for _, token := range tokens {
+		if token == topicWildcard {
+			return true
+		}
+	}
+	return tokens[len(tokens)-1] == topicTail
+}
+
+// topicNode is a node in the trie of subscription patterns, every level is a token of the pattern
+type topicNode struct {
+	children map[string]*topicNode
+	// handlers of the patterns ending at this node
+	handlers eventHandlers
+	// handlers of the patterns ending with the tail wildcard after this node
+	tail eventHandlers
+}
+
+// set replaces the handlers of the pattern, an empty list removes the pattern from the trie
+func (n *topicNode) set(pattern EventName, handlers eventHandlers) {
+	n.setTokens(strings.Split(pattern, topicSeparator), handlers)
+}
+
+func (n *topicNode) setTokens(tokens []string, handlers eventHandlers) {
+	if len(tokens) == 1 && tokens[0] == topicTail {
+		n.tail = handlers
+		return
+	}
+	if len(tokens) == 0 {
+		n.handlers = handlers
+		return
+	}
+
+	child, ok := n.children[tokens[0]]
+	if !ok {
+		if len(handlers) == 0 {
+			return
+		}
+		if n.children == nil {
+			n.children = make(map[string]*topicNode)
+		}
+		child = &topicNode{}
+		n.children[tokens[0]] = child
+	}
+
+	child.setTokens(tokens[1:], handlers)
+	if child.empty() {
+		delete(n.children, tokens[0])
+	}
+}
+
+func (n *topicNode) empty() bool {
+	return len(n.handlers) == 0 && len(n.tail) == 0 && len(n.children) == 0
+}
+
+// match returns the handlers of all the patterns matching the event name
+func (n *topicNode) match(name EventName) eventHandlers {
+	if n.empty() {
+		return nil
+	}
+	return n.matchTokens(strings.Split(name, topicSeparator), nil)
+}
+
+func (n *topicNode) matchTokens(tokens []string, res eventHandlers) eventHandlers {
+	if len(tokens) == 0 {
+		return append(res, n.handlers...)
+	}
+
+	res = append(res, n.tail...)
+	if child, ok := n.children[tokens[0]]; ok {
+		res = child.matchTokens(tokens[1:], res)
+	}
+	if child, ok := n.children[topicWildcard]; ok && tokens[0] != topicWildcard {
+		res = child.matchTokens(tokens[1:], res)
+	}
+	return res
+}
@@ -0,0 +1,105 @@
+package eventbus
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedEvent string
+
+func (e namedEvent) EventName() EventName {
+	return EventName(e)
+}
+
+func TestIsTopicPattern(t *testing.T) {
+	assert.True(t, isTopicPattern("order.*"))
+	assert.True(t, isTopicPattern("*.created"))
+	assert.True(t, isTopicPattern("billing.>"))
+	assert.True(t, isTopicPattern(">"))
+	assert.False(t, isTopicPattern("*"))
+	assert.False(t, isTopicPattern("order.created"))
+	assert.False(t, isTopicPattern("order.>.paid"))
+	assert.False(t, isTopicPattern("event:test1"))
+}
+
+func TestTopicPatternSubscriptions(t *testing.T) {
+	tests := map[EventName][]string{
+		"order.created":        {"order.created", "order.*", "*.created", ">", "catch-all"},
+		"order.shipped":        {"order.*", ">", "catch-all"},
+		"order.item.added":     {">", "catch-all"},
+		"billing.invoice.paid": {"billing.>", "billing.*.paid", ">", "catch-all"},
+		"billing":              {">", "catch-all"},
+		"event:test1":          {">", "catch-all"},
+	}
+
+	for _, newBus := range []func() EventBus{
+		func() EventBus { return New() },
+		func() EventBus { return NewAsync() },
+	} {
+		var mu sync.Mutex
+		var matched []string
+		bus := newBus()
+		for _, pattern := range []string{"order.created", "order.*", "*.created", "billing.>", "billing.*.paid", ">"} {
+			pattern := pattern
+			bus.Subscribe(EventHandlerFunc(func(event any) error {
+				mu.Lock()
+				matched = append(matched, pattern)
+				mu.Unlock()
+				return nil
+			}), pattern)
+		}
+		bus.Subscribe(EventHandlerFunc(func(event any) error {
+			mu.Lock()
+			matched = append(matched, "catch-all")
+			mu.Unlock()
+			return nil
+		}))
+
+		for name, expected := range tests {
+			matched = nil
+			if async, ok := bus.(AsyncEventBus); ok {
+				assert.NoError(t, async.PublishAsync(context.Background(), namedEvent(name)).Wait())
+			} else {
+				assert.NoError(t, bus.Publish(namedEvent(name)))
+			}
+
+			sort.Strings(expected)
+			sort.Strings(matched)
+			assert.Equal(t, expected, matched, "event %s", name)
+		}
+	}
+}
+
+func TestTopicPatternUnsubscribe(t *testing.T) {
+	called := 0
+	handler := EventHandlerFunc(func(event any) error {
+		called++
+		return nil
+	})
+
+	bus := New()
+	sub := bus.Subscribe(handler, "order.*")
+	bus.Subscribe(handler, "billing.>")
+
+	sub.Unsubscribe()
+	assert.NoError(t, bus.Publish(namedEvent("order.created")))
+	assert.Equal(t, 0, called)
+
+	bus.Unsubscribe(handler)
+	assert.NoError(t, bus.Publish(namedEvent("billing.invoice.paid")))
+	assert.Equal(t, 0, called)
+}
+
+func TestTopicTrieIsPrunedOnRemoval(t *testing.T) {
+	registry := newHandlerRegistry()
+	sub := registry.subscribe(EventHandlerFunc(func(event any) error { return nil }), []EventName{"a.b.*", "a.>"}, registry.remove)
+
+	sub.Unsubscribe()
+
+	assert.True(t, registry.patterns.empty())
+	assert.Empty(t, registry.handlers)
+}
@@ -0,0 +1,72 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrEventTypeMismatch is returned by typed handlers when the published event is not of the expected type.
+var ErrEventTypeMismatch = errors.New("event type mismatch")
+
+// SubscribeTyped subscribes a handler for the events of type T. The event name is derived from T,
+// when T is an interface the handler is registered as a wildcard handler.
+func SubscribeTyped[T Event](bus EventBus, handler func(T) error) Subscription {
+	return SubscribeTypedContext(bus, func(_ context.Context, event T) error {
+		return handler(event)
+	})
+}
+
+// SubscribeTypedContext is the context aware variant of SubscribeTyped.
+func SubscribeTypedContext[T Event](bus EventBus, handler func(context.Context, T) error) Subscription {
+	return bus.Subscribe(TypedHandler(handler), EventNameOf[T]())
+}
+
+// TypedHandler wraps a typed handler function in a ContextEventHandler that asserts the event type.
+// A pointer to T is dereferenced, like the EventHandlerResolver does for value receivers.
+// Events of any other type result in an ErrEventTypeMismatch error instead of a panic.
+func TypedHandler[T Event](handler func(context.Context, T) error) ContextEventHandler {
+	return ContextEventHandlerFunc(func(ctx context.Context, event any) error {
+		switch e := event.(type) {
+		case T:
+			return handler(ctx, e)
+		case *T:
+			if e != nil {
+				return handler(ctx, *e)
+			}
+		}
+		return fmt.Errorf("%w: expected %s, got %T", ErrEventTypeMismatch, eventTypeOf[T](), event)
+	})
+}
+
+// EventNameOf returns the event name for the type T, the wildcard "*" is returned for interfaces
+func EventNameOf[T Event]() EventName {
+	return typeEventName(eventTypeOf[T](), TypeNameStrategy)
+}
+
+func eventTypeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Publisher publishes events of a single type on the bus
+type Publisher[T Event] struct {
+	bus EventBus
+}
+
+func NewPublisher[T Event](bus EventBus) Publisher[T] {
+	return Publisher[T]{bus: bus}
+}
+
+// EventName returns the event name derived from T
+func (p Publisher[T]) EventName() EventName {
+	return EventNameOf[T]()
+}
+
+func (p Publisher[T]) Publish(event T) error {
+	return p.bus.Publish(event)
+}
+
+func (p Publisher[T]) PublishContext(ctx context.Context, event T) error {
+	return p.bus.PublishContext(ctx, event)
+}
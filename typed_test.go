@@ -0,0 +1,88 @@
+package eventbus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedEvent struct {
+	Message string
+}
+
+func (typedEvent) EventName() EventName {
+	return "typed.event"
+}
+
+func TestSubscribeTyped(t *testing.T) {
+	var received []string
+	bus := New()
+	SubscribeTyped(bus, func(e typedEvent) error {
+		received = append(received, e.Message)
+		return nil
+	})
+
+	assert.NoError(t, bus.Publish(typedEvent{Message: "value"}))
+	assert.NoError(t, bus.Publish(&typedEvent{Message: "pointer"}))
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+
+	assert.Equal(t, []string{"value", "pointer"}, received)
+}
+
+func TestSubscribeTypedWildcard(t *testing.T) {
+	var received []EventName
+	bus := New()
+	SubscribeTyped(bus, func(e Event) error {
+		received = append(received, e.EventName())
+		return nil
+	})
+
+	assert.NoError(t, bus.Publish(typedEvent{}))
+	assert.NoError(t, bus.Publish(&TestEventA{}))
+
+	assert.Equal(t, []EventName{"typed.event", EventA}, received)
+}
+
+func TestSubscribeTypedMismatchReturnsError(t *testing.T) {
+	bus := New(WithEventNameResolver(func(event any) string {
+		return "typed.event"
+	}))
+	SubscribeTyped(bus, func(e typedEvent) error {
+		t.Fatal("expected handler not to be called")
+		return nil
+	})
+
+	err := bus.Publish(&TestEventA{})
+
+	assert.True(t, errors.Is(err, ErrEventTypeMismatch))
+}
+
+func TestSubscribeTypedUnsubscribe(t *testing.T) {
+	called := 0
+	bus := New()
+	sub := SubscribeTyped(bus, func(e typedEvent) error {
+		called++
+		return nil
+	})
+
+	sub.Unsubscribe()
+	assert.NoError(t, bus.Publish(typedEvent{}))
+
+	assert.Equal(t, 0, called)
+}
+
+func TestPublisher(t *testing.T) {
+	var received []string
+	bus := New()
+	SubscribeTyped(bus, func(e typedEvent) error {
+		received = append(received, e.Message)
+		return nil
+	})
+
+	publisher := NewPublisher[typedEvent](bus)
+
+	assert.Equal(t, EventName("typed.event"), publisher.EventName())
+	assert.NoError(t, publisher.Publish(typedEvent{Message: "hello"}))
+	assert.Equal(t, []string{"hello"}, received)
+}
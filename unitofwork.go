@@ -0,0 +1,119 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrUnitOfWorkCompleted is returned when publishing on, or committing, a unit of work that is already committed or rolled back
+var ErrUnitOfWorkCompleted = errors.New("unit of work completed")
+
+// UnitOfWork is a bus that buffers the published events until it is committed, the subscriptions are passed to the
+// underlying bus. The envelope of an event is created when it is published, so the causation is kept.
+type UnitOfWork struct {
+	bus       EventBus
+	mu        sync.Mutex
+	events    []*Envelope
+	completed bool
+}
+
+// NewUnitOfWork creates a unit of work publishing on the bus when it is committed
+func NewUnitOfWork(bus EventBus) *UnitOfWork {
+	return &UnitOfWork{bus: bus}
+}
+
+func (u *UnitOfWork) Subscribe(handler EventHandler, events ...EventName) Subscription {
+	return u.bus.Subscribe(handler, events...)
+}
+
+func (u *UnitOfWork) Unsubscribe(handler EventHandler, events ...EventName) {
+	u.bus.Unsubscribe(handler, events...)
+}
+
+func (u *UnitOfWork) findSubscription(handlerName string) (*subscription, bool) {
+	return findSubscription(u.bus, handlerName)
+}
+
+func (u *UnitOfWork) reportError(err error, event any) error {
+	return reportError(u.bus, err, event)
+}
+
+func (u *UnitOfWork) eventName(event any) (EventName, error) {
+	return busEventName(u.bus, event)
+}
+
+func (u *UnitOfWork) Publish(event any) error {
+	return u.PublishContext(context.Background(), event)
+}
+
+// PublishContext buffers the event until the unit of work is committed
+func (u *UnitOfWork) PublishContext(ctx context.Context, event any) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.completed {
+		return ErrUnitOfWorkCompleted
+	}
+	u.events = append(u.events, newEnvelope(ctx, event, ""))
+	return nil
+}
+
+// Commit publishes the buffered events in order on the underlying bus. Publishing stops at the first error,
+// the remaining events are discarded. The unit of work is completed after a commit.
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	u.mu.Lock()
+	if u.completed {
+		u.mu.Unlock()
+		return ErrUnitOfWorkCompleted
+	}
+	events := u.events
+	u.events, u.completed = nil, true
+	u.mu.Unlock()
+
+	for _, event := range events {
+		if err := u.bus.PublishContext(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback discards the buffered events, the unit of work is completed after a rollback
+func (u *UnitOfWork) Rollback() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.events, u.completed = nil, true
+}
+
+type unitOfWorkKey struct{}
+
+// ContextWithUnitOfWork returns a context carrying the unit of work
+func ContextWithUnitOfWork(ctx context.Context, uow *UnitOfWork) context.Context {
+	return context.WithValue(ctx, unitOfWorkKey{}, uow)
+}
+
+// UnitOfWorkFromContext returns the unit of work carried by the context
+func UnitOfWorkFromContext(ctx context.Context) (*UnitOfWork, bool) {
+	uow, ok := ctx.Value(unitOfWorkKey{}).(*UnitOfWork)
+	return uow, ok
+}
+
+// BusFromContext returns the unit of work carried by the context, or the bus when there is none
+func BusFromContext(ctx context.Context, bus EventBus) EventBus {
+	if uow, ok := UnitOfWorkFromContext(ctx); ok {
+		return uow
+	}
+	return bus
+}
+
+// WithinUnitOfWork runs the func with a unit of work on the bus in the context. The unit of work is committed when
+// the func succeeds and rolled back when it returns an error or panics.
+func WithinUnitOfWork(ctx context.Context, bus EventBus, fn func(ctx context.Context) error) error {
+	uow := NewUnitOfWork(bus)
+	defer uow.Rollback()
+
+	if err := fn(ContextWithUnitOfWork(ctx, uow)); err != nil {
+		return err
+	}
+	return uow.Commit(ctx)
+}
@@ -0,0 +1,94 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitOfWorkCommit(t *testing.T) {
+	bus := New()
+	var published []any
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		published = append(published, event)
+		return nil
+	}), "*")
+
+	uow := NewUnitOfWork(bus)
+	assert.NoError(t, uow.Publish(namedEvent("a")))
+	assert.NoError(t, uow.Publish(namedEvent("b")))
+	assert.Empty(t, published)
+
+	assert.NoError(t, uow.Commit(context.Background()))
+	assert.Equal(t, []any{namedEvent("a"), namedEvent("b")}, published)
+
+	assert.ErrorIs(t, uow.Publish(namedEvent("c")), ErrUnitOfWorkCompleted)
+	assert.ErrorIs(t, uow.Commit(context.Background()), ErrUnitOfWorkCompleted)
+}
+
+func TestUnitOfWorkRollback(t *testing.T) {
+	bus := New()
+	called := false
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		called = true
+		return nil
+	}), "*")
+
+	uow := NewUnitOfWork(bus)
+	assert.NoError(t, uow.Publish(namedEvent("a")))
+	uow.Rollback()
+
+	assert.ErrorIs(t, uow.Commit(context.Background()), ErrUnitOfWorkCompleted)
+	assert.False(t, called)
+}
+
+func TestUnitOfWorkKeepsCausation(t *testing.T) {
+	bus := New()
+	var envelope *Envelope
+	bus.Subscribe(EnvelopeHandlerFunc(func(ctx context.Context, env *Envelope) error {
+		envelope = env
+		return nil
+	}), "b")
+
+	parent := &Envelope{ID: "parent", CorrelationID: "correlation"}
+	uow := NewUnitOfWork(bus)
+	assert.NoError(t, uow.PublishContext(ContextWithEnvelope(context.Background(), parent), namedEvent("b")))
+	assert.NoError(t, uow.Commit(context.Background()))
+
+	assert.Equal(t, "parent", envelope.CausationID)
+	assert.Equal(t, "correlation", envelope.CorrelationID)
+}
+
+func TestWithinUnitOfWork(t *testing.T) {
+	bus := New()
+	var published []any
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		published = append(published, event)
+		return nil
+	}), "*")
+
+	publish := func(ctx context.Context, event any) error {
+		return BusFromContext(ctx, bus).PublishContext(ctx, event)
+	}
+
+	err := WithinUnitOfWork(context.Background(), bus, func(ctx context.Context) error {
+		_, ok := UnitOfWorkFromContext(ctx)
+		assert.True(t, ok)
+		assert.NoError(t, publish(ctx, namedEvent("a")))
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+	assert.Empty(t, published)
+
+	err = WithinUnitOfWork(context.Background(), bus, func(ctx context.Context) error {
+		return publish(ctx, namedEvent("b"))
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{namedEvent("b")}, published)
+
+	//without a unit of work the event is published directly
+	assert.NoError(t, publish(context.Background(), namedEvent("c")))
+	assert.Len(t, published, 2)
+}
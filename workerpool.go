@@ -0,0 +1,152 @@
+package eventbus
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrQueueFull is returned by a worker pool bus with the QueueReject policy when the queue is full
+	ErrQueueFull = errors.New("event queue full")
+
+	// ErrEventDropped is passed to the error handler when a handler call is dropped from a full queue
+	ErrEventDropped = errors.New("event dropped")
+)
+
+// QueuePolicy determines what a worker pool bus does when its queue is full
+type QueuePolicy int
+
+const (
+	// QueueBlock blocks the publisher until there is room in the queue or the publish context is done
+	QueueBlock QueuePolicy = iota
+	// QueueDropNewest drops the handler call of the event that is published
+	QueueDropNewest
+	// QueueDropOldest drops the oldest queued handler call to make room for the published event
+	QueueDropOldest
+	// QueueReject rejects the event and returns ErrQueueFull to the publisher
+	QueueReject
+)
+
+type queuePolicySetter interface {
+	setQueuePolicy(QueuePolicy)
+}
+
+func (eb *asyncEventBus) setQueuePolicy(policy QueuePolicy) {
+	pool, ok := eb.dispatcher.(*workerPool)
+	if !ok {
+		panic("eventbus: the queue policy only applies to a worker pool bus")
+	}
+	pool.policy = policy
+}
+
+type workerPool struct {
+	jobs   chan asyncJob
+	policy QueuePolicy
+	run    func(asyncJob)
+	drop   func(asyncJob, error)
+	done   chan struct{}
+	once   sync.Once
+	// mu is held by the dispatching publishers, stop waits for them before it abandons the queued jobs
+	mu sync.RWMutex
+}
+
+func newWorkerPool(queueSize int, run func(asyncJob), drop func(asyncJob, error)) *workerPool {
+	return &workerPool{
+		jobs: make(chan asyncJob, queueSize),
+		run:  run,
+		drop: drop,
+		done: make(chan struct{}),
+	}
+}
+
+func (p *workerPool) start(workers int) {
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+}
+
+func (p *workerPool) work() {
+	for {
+		select {
+		case job := <-p.jobs:
+			p.run(job)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *workerPool) dispatch(job asyncJob) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	//a job is never queued after the pool is stopped
+	select {
+	case <-p.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case p.jobs <- job:
+		return nil
+	case <-p.done:
+		return ErrClosed
+	default:
+	}
+
+	switch p.policy {
+	case QueueDropNewest:
+		p.drop(job, ErrEventDropped)
+		return nil
+	case QueueDropOldest:
+		for {
+			select {
+			case p.jobs <- job:
+				return nil
+			case oldest := <-p.jobs:
+				p.drop(oldest, ErrEventDropped)
+			}
+		}
+	case QueueReject:
+		return ErrQueueFull
+	default:
+		select {
+		case p.jobs <- job:
+			return nil
+		case <-job.ctx.Done():
+			return job.ctx.Err()
+		case <-p.done:
+			return ErrClosed
+		}
+	}
+}
+
+func (p *workerPool) stop() {
+	p.once.Do(func() {
+		close(p.done)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		abandonJobs(p.jobs)
+	})
+}
+
+// NewAsyncPool creates an async bus that runs the handlers on a fixed number of workers. Every handler call
+// of a published event is queued, when the queue is full the QueuePolicy set by WithQueuePolicy is applied,
+// by default the publisher blocks. Close the bus to stop the workers.
+func NewAsyncPool(workers int, queueSize int, options ...Option) AsyncEventBus {
+	if workers < 1 {
+		workers = 1
+	}
+
+	eb := newAsyncEventBus()
+	pool := newWorkerPool(queueSize, eb.handle, eb.drop)
+	eb.dispatcher = pool
+
+	for _, option := range options {
+		option(eb)
+	}
+
+	pool.start(workers)
+	return eb
+}
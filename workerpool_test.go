@@ -0,0 +1,191 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingHandler blocks every call until it is released, started receives a signal for every call
+type blockingHandler struct {
+	started chan any
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{
+		started: make(chan any, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (h *blockingHandler) Handle(event any) error {
+	h.started <- event
+	<-h.release
+	return nil
+}
+
+func TestAsyncPoolLimitsConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	bus := NewAsyncPool(2, 10)
+	bus.Subscribe(EventHandlerFunc(func(event any) error {
+		n := running.Add(1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, bus.Publish(&TestEventA{}))
+		}()
+	}
+	wg.Wait()
+
+	_, err := bus.Close(context.Background())
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
+func TestAsyncPoolQueuePolicies(t *testing.T) {
+	tests := map[string]struct {
+		policy      QueuePolicy
+		expectedErr error
+		handled     []any
+		dropped     []any
+	}{
+		"reject":      {policy: QueueReject, expectedErr: ErrQueueFull, handled: []any{1, 2}},
+		"drop newest": {policy: QueueDropNewest, handled: []any{1, 2}, dropped: []any{3}},
+		"drop oldest": {policy: QueueDropOldest, handled: []any{1, 3}, dropped: []any{2}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var dropped []any
+			handler := newBlockingHandler()
+
+			bus := NewAsyncPool(1, 1, WithQueuePolicy(test.policy), WithErrorHandler(func(err error, event any) error {
+				assert.ErrorIs(t, err, ErrEventDropped)
+				mu.Lock()
+				dropped = append(dropped, event)
+				mu.Unlock()
+				return nil
+			}))
+			bus.Subscribe(handler)
+
+			//the first event occupies the worker, the second fills the queue
+			assert.NoError(t, bus.Publish(1))
+			<-handler.started
+			assert.NoError(t, bus.Publish(2))
+			assert.ErrorIs(t, bus.Publish(3), test.expectedErr)
+
+			close(handler.release)
+			_, err := bus.Close(context.Background())
+			assert.NoError(t, err)
+
+			var handled []any
+			close(handler.started)
+			for event := range handler.started {
+				handled = append(handled, event)
+			}
+			assert.Equal(t, test.handled, append([]any{1}, handled...))
+			assert.Equal(t, test.dropped, dropped)
+		})
+	}
+}
+
+func TestAsyncPoolBlockPolicyHonoursContext(t *testing.T) {
+	handler := newBlockingHandler()
+	defer close(handler.release)
+
+	bus := NewAsyncPool(1, 1)
+	bus.Subscribe(handler)
+
+	assert.NoError(t, bus.Publish(1))
+	<-handler.started
+	assert.NoError(t, bus.Publish(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	result := bus.PublishAsync(ctx, 3)
+
+	assert.ErrorIs(t, result.Wait(), context.DeadlineExceeded)
+}
+
+func TestAsyncPoolCloseReportsAbandonedEvents(t *testing.T) {
+	handler := newBlockingHandler()
+	defer close(handler.release)
+
+	bus := NewAsyncPool(1, 5)
+	bus.Subscribe(handler)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, bus.Publish(i))
+	}
+	<-handler.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	abandoned, err := bus.Close(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 3, abandoned)
+}
+
+func TestAsyncCloseFinishesQueuedEvents(t *testing.T) {
+	for name, newBus := range map[string]func() AsyncEventBus{
+		"pool": func() AsyncEventBus { return NewAsyncPool(1, 5) },
+		"partitioned": func() AsyncEventBus {
+			return NewAsyncPartitioned(1, 5, func(event any) string { return "" })
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			handler := newBlockingHandler()
+			defer close(handler.release)
+
+			bus := newBus()
+			bus.Subscribe(handler)
+			running := bus.PublishAsync(context.Background(), 1)
+			<-handler.started
+			queued := bus.PublishAsync(context.Background(), 2)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			_, err := bus.Close(ctx)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+			select {
+			case <-queued.Done():
+				assert.ErrorIs(t, queued.Err(), ErrClosed)
+			case <-time.After(time.Second):
+				t.Fatal("the queued event is not finished")
+			}
+			assert.ErrorIs(t, bus.PublishAsync(context.Background(), 3).Wait(), ErrClosed)
+
+			handler.release <- struct{}{}
+			assert.NoError(t, running.Wait())
+		})
+	}
+}
+
+func TestQueuePolicyPanicsOnOtherBuses(t *testing.T) {
+	assert.Panics(t, func() {
+		NewAsync(WithQueuePolicy(QueueReject))
+	})
+	assert.Panics(t, func() {
+		New(WithQueuePolicy(QueueReject))
+	})
+}